@@ -40,6 +40,10 @@ object UserSettings {
     const val KEY_SYNC_OFFSET_MS = "sync_offset_ms"
     const val KEY_LOW_MEMORY_MODE = "low_memory_mode"
     const val KEY_PREFERRED_CODEC = "preferred_codec"
+    const val KEY_BALANCE = "balance"
+    const val KEY_MONO_OUTPUT = "mono_output"
+    const val KEY_DISABLE_CLOCK_SYNC = "disable_clock_sync"
+    const val KEY_LOCAL_BUFFER_MS = "local_buffer_ms"
     const val KEY_FULL_SCREEN_MODE = "full_screen_mode"
     const val KEY_KEEP_SCREEN_ON = "keep_screen_on"
     const val KEY_HIGH_POWER_MODE = "high_power_mode"
@@ -63,6 +67,11 @@ object UserSettings {
     const val SYNC_OFFSET_MAX = 5000
     const val SYNC_OFFSET_DEFAULT = 0
 
+    // Local buffer range limits (milliseconds), used when clock sync is disabled
+    const val LOCAL_BUFFER_MIN = 200
+    const val LOCAL_BUFFER_MAX = 5000
+    const val LOCAL_BUFFER_DEFAULT = 1000
+
     /** Non-sensitive UI/app preferences (default SharedPreferences). */
     @Volatile
     private var prefs: SharedPreferences? = null
@@ -244,6 +253,42 @@ object UserSettings {
         prefs?.edit()?.putInt(KEY_SYNC_OFFSET_MS, clamped)?.apply()
     }
 
+    /**
+     * Local stereo balance, -1.0 (full left) to +1.0 (full right), 0.0 centered.
+     * Applied on this device only; the server never sees it.
+     */
+    var balance: Float
+        get() = prefs?.getFloat(KEY_BALANCE, 0f)?.coerceIn(-1f, 1f) ?: 0f
+        set(value) { prefs?.edit()?.putFloat(KEY_BALANCE, value.coerceIn(-1f, 1f))?.apply() }
+
+    /**
+     * Whether stereo output is downmixed to mono on this device
+     * (accessibility, hearing in one ear).
+     */
+    var monoOutput: Boolean
+        get() = prefs?.getBoolean(KEY_MONO_OUTPUT, false) ?: false
+        set(value) { prefs?.edit()?.putBoolean(KEY_MONO_OUTPUT, value)?.apply() }
+
+    /**
+     * Whether clock sync is turned off so audio plays at the device's own
+     * clock (single-device listening; breaks multi-room alignment).
+     */
+    var disableClockSync: Boolean
+        get() = prefs?.getBoolean(KEY_DISABLE_CLOCK_SYNC, false) ?: false
+        set(value) { prefs?.edit()?.putBoolean(KEY_DISABLE_CLOCK_SYNC, value)?.apply() }
+
+    /**
+     * How much audio to queue before starting (and after an underrun)
+     * while clock sync is disabled, in milliseconds. Clamped to
+     * [LOCAL_BUFFER_MIN]..[LOCAL_BUFFER_MAX].
+     */
+    var localBufferMs: Int
+        get() = (prefs?.getInt(KEY_LOCAL_BUFFER_MS, LOCAL_BUFFER_DEFAULT) ?: LOCAL_BUFFER_DEFAULT)
+            .coerceIn(LOCAL_BUFFER_MIN, LOCAL_BUFFER_MAX)
+        set(value) {
+            prefs?.edit()?.putInt(KEY_LOCAL_BUFFER_MS, value.coerceIn(LOCAL_BUFFER_MIN, LOCAL_BUFFER_MAX))?.apply()
+        }
+
     /**
      * Whether Low Memory Mode is enabled.
      * When enabled:
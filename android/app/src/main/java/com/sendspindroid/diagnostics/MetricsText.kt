@@ -0,0 +1,53 @@
+package com.sendspindroid.diagnostics
+
+/**
+ * Renders the stats counters as Prometheus / OpenMetrics text exposition, so a
+ * home-lab dashboard can scrape them.
+ *
+ * Takes the stats as a key lookup (in production, the
+ * [com.sendspindroid.playback.PlaybackService] stats bundle) so it stays pure
+ * and unit-testable. Keys that are absent or non-numeric are skipped, so a
+ * partially populated snapshot (e.g. no audio player yet) still renders.
+ * Plain string building, no client library.
+ */
+object MetricsText {
+
+    private const val PREFIX = "sendspin_"
+
+    private enum class Type(val text: String) { COUNTER("counter"), GAUGE("gauge") }
+
+    private class Metric(val key: String, val name: String, val type: Type, val help: String)
+
+    private val metrics = listOf(
+        Metric("chunks_received", "audio_chunks_received", Type.COUNTER, "Audio chunks received from the server."),
+        Metric("chunks_played", "audio_chunks_played", Type.COUNTER, "Audio chunks written to the output."),
+        Metric("chunks_dropped", "audio_chunks_dropped", Type.COUNTER, "Audio chunks dropped before playback."),
+        Metric("frames_dropped", "sync_frames_dropped", Type.COUNTER, "Frames dropped by sync correction."),
+        Metric("frames_inserted", "sync_frames_inserted", Type.COUNTER, "Frames inserted by sync correction."),
+        Metric("buffer_underrun_count", "buffer_underruns", Type.COUNTER, "Playback loop underruns."),
+        Metric("reanchor_count", "reanchors", Type.COUNTER, "Playback reanchors after a large sync error."),
+        Metric("gaps_filled", "stream_gaps_filled", Type.COUNTER, "Stream gaps filled with silence."),
+        Metric("reconnect_attempts_total", "reconnect_attempts", Type.COUNTER, "Reconnect attempts since the process started."),
+        Metric("bytes_received_total", "received_bytes", Type.COUNTER, "Payload bytes received from the server."),
+        Metric("rtt_us", "rtt_microseconds", Type.GAUGE, "Most recent clock sync round-trip time."),
+        Metric("clock_error_us", "clock_error_microseconds", Type.GAUGE, "Estimated clock offset error."),
+        Metric("sync_error_us", "sync_error_microseconds", Type.GAUGE, "Current playback sync error."),
+        Metric("queued_samples", "queued_samples", Type.GAUGE, "Samples queued for playback."),
+    )
+
+    /**
+     * @param lookup Returns the stat for a stats-bundle key, or null if absent.
+     * @return OpenMetrics text, terminated by `# EOF`.
+     */
+    fun format(lookup: (String) -> Any?): String = buildString {
+        for (metric in metrics) {
+            val value = lookup(metric.key) as? Number ?: continue
+            // OpenMetrics requires counter samples to carry the _total suffix
+            val sample = if (metric.type == Type.COUNTER) "${metric.name}_total" else metric.name
+            append("# TYPE ").append(PREFIX).append(metric.name).append(' ').append(metric.type.text).append('\n')
+            append("# HELP ").append(PREFIX).append(metric.name).append(' ').append(metric.help).append('\n')
+            append(PREFIX).append(sample).append(' ').append(value.toLong()).append('\n')
+        }
+        append("# EOF\n")
+    }
+}
@@ -0,0 +1,80 @@
+package com.sendspindroid.playback
+
+/**
+ * Decides what local playback does across audio focus changes, and
+ * remembers whether it was playing before a loss so regaining focus
+ * resumes only what the loss interrupted.
+ *
+ * - Transient loss (call, navigation prompt): pause, and note whether
+ *   playback was running. A server "playing" that arrives during the loss
+ *   is deferred rather than talking over the call ([onPlayRequested]).
+ * - Pause during the loss (user or server): nothing to resume afterwards.
+ * - Gain: resume if playback was interrupted and the server still has us
+ *   playing.
+ * - Permanent loss: another app owns the output; never auto-resume.
+ *
+ * Ducking is stateless (the sink's track gain) and stays with the caller.
+ *
+ * Pure and deterministic, with no Android dependencies. Not thread-safe;
+ * PlaybackService drives it from the main thread.
+ */
+class AudioFocusTracker {
+
+    /** True between a transient loss and the next gain, permanent loss or [reset]. */
+    var isLostTransiently: Boolean = false
+        private set
+
+    /** Whether playback was running (or was asked to start) when focus went away. */
+    var wasPlayingBeforeLoss: Boolean = false
+        private set
+
+    /**
+     * Focus lost transiently; the caller pauses. Only the first loss of a
+     * run records [playing], so a second loss doesn't forget the original
+     * state.
+     */
+    fun onTransientLoss(playing: Boolean) {
+        if (!isLostTransiently) {
+            wasPlayingBeforeLoss = playing
+        }
+        isLostTransiently = true
+    }
+
+    /** Focus lost for good; the caller pauses and nothing resumes on its own. */
+    fun onPermanentLoss() = reset()
+
+    /**
+     * Focus is back.
+     *
+     * @param serverPlaying whether the server currently has this player playing
+     * @return `true` if the caller should resume local playback
+     */
+    fun onGain(serverPlaying: Boolean): Boolean {
+        val resume = wasPlayingBeforeLoss && serverPlaying
+        reset()
+        return resume
+    }
+
+    /**
+     * The server asked to play.
+     *
+     * @return `true` if the resume must wait for focus to return; it is
+     *   then remembered and [onGain] performs it
+     */
+    fun onPlayRequested(): Boolean {
+        if (!isLostTransiently) return false
+        wasPlayingBeforeLoss = true
+        return true
+    }
+
+    /** Playback paused (by the user or the server); a later gain must not resume it. */
+    fun onPaused() {
+        wasPlayingBeforeLoss = false
+    }
+
+    /** Forget everything, e.g. when focus is abandoned. */
+    fun reset() {
+        isLostTransiently = false
+        wasPlayingBeforeLoss = false
+    }
+}
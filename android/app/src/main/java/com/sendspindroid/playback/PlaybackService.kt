@@ -8,7 +8,10 @@ import android.content.IntentFilter
 import android.content.pm.ServiceInfo
 import android.database.ContentObserver
 import android.media.AudioAttributes as AndroidAudioAttributes
+import android.media.AudioDeviceCallback
+import android.media.AudioDeviceInfo
 import android.media.AudioFocusRequest
+import android.media.AudioFormat
 import android.media.AudioManager
 import android.provider.Settings
 import android.graphics.Bitmap
@@ -19,6 +22,7 @@ import android.os.Bundle
 import android.os.Handler
 import android.os.Looper
 import android.os.PowerManager
+import android.os.SystemClock
 import android.util.Log
 import androidx.core.app.NotificationCompat
 import androidx.core.app.ServiceCompat
@@ -51,6 +55,7 @@ import com.sendspindroid.coordinator.FailureReason
 import com.sendspindroid.coordinator.ReconnectStatus
 import com.sendspindroid.coordinator.TransportState
 import com.sendspindroid.diagnostics.HandoffEpisodeRecorder
+import com.sendspindroid.diagnostics.MetricsText
 import com.sendspindroid.diagnostics.Telemetry
 import com.sendspindroid.logging.AppLog
 import com.sendspindroid.logging.LogLevel
@@ -287,6 +292,43 @@ class PlaybackService : MediaLibraryService() {
         }
     }
 
+    // Local balance from settings; applied to the live player and to each new one
+    private val balanceReceiver = object : BroadcastReceiver() {
+        override fun onReceive(context: Context, intent: Intent) {
+            val balance = intent.getFloatExtra(SettingsViewModel.EXTRA_BALANCE, 0f)
+            syncAudioPlayer?.setBalance(balance)
+        }
+    }
+
+    // Mono downmix from settings; applied like balance
+    private val monoOutputReceiver = object : BroadcastReceiver() {
+        override fun onReceive(context: Context, intent: Intent) {
+            val enabled = intent.getBooleanExtra(SettingsViewModel.EXTRA_MONO_OUTPUT_ENABLED, false)
+            syncAudioPlayer?.setMonoOutput(enabled)
+        }
+    }
+
+    // Clock sync on/off from settings; applied to both the client's time sync
+    // and the live player, and to each new one
+    private val clockSyncReceiver = object : BroadcastReceiver() {
+        override fun onReceive(context: Context, intent: Intent) {
+            val disabled = intent.getBooleanExtra(SettingsViewModel.EXTRA_CLOCK_SYNC_DISABLED, false)
+            Log.i(TAG, "Clock sync disabled changed: $disabled")
+            sendSpinClient?.clockSyncDisabled = disabled
+            syncAudioPlayer?.setFreeRunning(disabled)
+        }
+    }
+
+    // Local buffer amount from settings; only used while clock sync is disabled
+    private val localBufferReceiver = object : BroadcastReceiver() {
+        override fun onReceive(context: Context, intent: Intent) {
+            val bufferMs = intent.getIntExtra(
+                SettingsViewModel.EXTRA_LOCAL_BUFFER_MS, com.sendspindroid.UserSettings.LOCAL_BUFFER_DEFAULT
+            )
+            syncAudioPlayer?.setFreeRunning(com.sendspindroid.UserSettings.disableClockSync, bufferMs.toLong())
+        }
+    }
+
     // Flag to prevent callbacks from executing after service is destroyed
     @Volatile
     private var isDestroyed = false
@@ -312,6 +354,16 @@ class PlaybackService : MediaLibraryService() {
     private var lastSyncOffsetMs: Double = 0.0
     private var lastSyncOffsetSource: String = ""
 
+    // Health warnings from SendSpin, surfaced in getStats(). The clock-sync one
+    // stays until the filter converges again; the stream ones reset on each
+    // stream/start. -1 means no warning.
+    @Volatile
+    private var clockSyncUnstableJitterUs: Long = -1L
+    @Volatile
+    private var serverOverflowBytesPerSec: Long = -1L
+    @Volatile
+    private var audioStartedForStream: Boolean = false
+
     // Artwork state. Two independent sources are maintained so the lock-screen
     // widget and app UI don't diverge when the SendSpin server sends a binary
     // artwork payload whose contents don't match the `artwork_url` in the
@@ -412,10 +464,36 @@ class PlaybackService : MediaLibraryService() {
     private var volumeObserverRegistered: Boolean = false  // Track registration state to prevent leaks
     private var lastKnownVolume: Int = -1  // Track to detect external volume changes
 
+    // Re-reports output capabilities when the route changes (Bluetooth
+    // headset connects, USB DAC unplugged) so a running stream can switch
+    // to a format the new device plays. Debounced because one Bluetooth
+    // device fires several callbacks (A2DP + SCO).
+    private val applyOutputCapabilitiesRunnable = Runnable { applyOutputCapabilities() }
+    private val outputDeviceCallback = object : AudioDeviceCallback() {
+        override fun onAudioDevicesAdded(addedDevices: Array<out AudioDeviceInfo>) =
+            scheduleApplyOutputCapabilities()
+
+        override fun onAudioDevicesRemoved(removedDevices: Array<out AudioDeviceInfo>) =
+            scheduleApplyOutputCapabilities()
+    }
+
     // Audio focus management - required for Android Auto to hand over audio output
     private var audioFocusRequest: AudioFocusRequest? = null
     private var hasAudioFocus: Boolean = false
 
+    // Transient focus loss (call, navigation prompt): audio is paused until
+    // AUDIOFOCUS_GAIN, which resumes only what the loss interrupted. Main
+    // thread only.
+    private val focusTracker = AudioFocusTracker()
+
+    /**
+     * Whether local playback was running when audio focus was last lost
+     * transiently, i.e. what AUDIOFOCUS_GAIN will resume. False once focus
+     * returns, is lost for good, or playback is paused meanwhile.
+     */
+    val wasPlayingBeforeFocusLoss: Boolean
+        get() = focusTracker.wasPlayingBeforeLoss
+
     // Receiver for ACTION_AUDIO_BECOMING_NOISY: fired when the audio output is
     // rerouting to the built-in speaker because an external output disconnected
     // (wired headphones unplugged, Bluetooth/Android Auto disconnected). We pause
@@ -435,6 +513,14 @@ class PlaybackService : MediaLibraryService() {
         // Debug logging interval (1 sample per second)
         private const val DEBUG_LOG_INTERVAL_MS = 1000L
 
+        // How often SendSpin re-anchors the track position while playing, so
+        // playbackState doesn't drift between server/state messages.
+        private const val POSITION_UPDATE_INTERVAL_MS = 1000L
+
+        // Settle time after an output device change before re-reporting
+        // capabilities, so one Bluetooth connect triggers a single update.
+        private const val OUTPUT_DEVICE_DEBOUNCE_MS = 500L
+
         // Timeout for awaiting a terminal connection state in connectViaSelectedConnection.
         private const val CONNECT_TIMEOUT_MS = 15_000L
 
@@ -452,6 +538,9 @@ class PlaybackService : MediaLibraryService() {
         const val COMMAND_PREVIOUS = "com.sendspindroid.PREVIOUS"
         const val COMMAND_SWITCH_GROUP = "com.sendspindroid.SWITCH_GROUP"
         const val COMMAND_GET_STATS = "com.sendspindroid.GET_STATS"
+        const val COMMAND_GET_METRICS = "com.sendspindroid.GET_METRICS"
+        const val EXTRA_METRICS_TEXT = "metrics_text"
+        const val COMMAND_FLUSH_BUFFERS = "com.sendspindroid.FLUSH_BUFFERS"
         const val COMMAND_CONNECT_REMOTE = "com.sendspindroid.CONNECT_REMOTE"
         const val COMMAND_CONNECT_PROXY = "com.sendspindroid.CONNECT_PROXY"
 
@@ -686,6 +775,30 @@ class PlaybackService : MediaLibraryService() {
             IntentFilter(SettingsViewModel.ACTION_PREFERRED_CODEC_CHANGED)
         )
 
+        // Register receiver for balance changes from settings
+        LocalBroadcastManager.getInstance(this).registerReceiver(
+            balanceReceiver,
+            IntentFilter(SettingsViewModel.ACTION_BALANCE_CHANGED)
+        )
+
+        // Register receiver for mono output changes from settings
+        LocalBroadcastManager.getInstance(this).registerReceiver(
+            monoOutputReceiver,
+            IntentFilter(SettingsViewModel.ACTION_MONO_OUTPUT_CHANGED)
+        )
+
+        // Register receiver for clock sync on/off from settings
+        LocalBroadcastManager.getInstance(this).registerReceiver(
+            clockSyncReceiver,
+            IntentFilter(SettingsViewModel.ACTION_CLOCK_SYNC_CHANGED)
+        )
+
+        // Register receiver for local buffer changes from settings
+        LocalBroadcastManager.getInstance(this).registerReceiver(
+            localBufferReceiver,
+            IntentFilter(SettingsViewModel.ACTION_LOCAL_BUFFER_CHANGED)
+        )
+
         // Initialize Coil ImageLoader for artwork fetching (skip in low memory mode)
         if (!com.sendspindroid.UserSettings.lowMemoryMode) {
             imageLoader = ImageLoader.Builder(this)
@@ -1068,6 +1181,8 @@ class PlaybackService : MediaLibraryService() {
         )
         volumeObserverRegistered = true
 
+        audioManager?.registerAudioDeviceCallback(outputDeviceCallback, mainHandler)
+
         Log.d(TAG, "Volume control initialized - using device STREAM_MUSIC")
     }
 
@@ -1085,6 +1200,8 @@ class PlaybackService : MediaLibraryService() {
                 callback = SendSpinClientCallback()
             )
             sendSpinClient?.selfReconnectEnabled = false
+            sendSpinClient?.clockSyncDisabled = com.sendspindroid.UserSettings.disableClockSync
+            sendSpinClient?.setPositionUpdateInterval(POSITION_UPDATE_INTERVAL_MS)
             sendSpinPlayer?.setSendSpinClient(sendSpinClient)
             Log.d(TAG, "SendSpin initialized with name: $playerName")
         } catch (e: Exception) {
@@ -1242,17 +1359,37 @@ class PlaybackService : MediaLibraryService() {
      */
     private inner class SendSpinClientCallback : SendSpin.Callback {
 
+        /**
+         * Post [block] to the main thread unless the connection that produced
+         * the event has been superseded by the time it runs. Without this a
+         * last metadata/state update from the old server can land after a
+         * reconnect and overwrite the new connection's state.
+         */
+        private fun postForCurrentConnection(event: String, block: () -> Unit) {
+            val client = sendSpinClient
+            val generation = client?.getConnectionGeneration()
+            mainHandler.post {
+                if (!isCurrentConnection(client, generation)) {
+                    Log.d(TAG, "Dropping $event from superseded connection")
+                    return@post
+                }
+                block()
+            }
+        }
+
+        private fun isCurrentConnection(client: SendSpin?, generation: Long?): Boolean =
+            client === sendSpinClient && generation == client?.getConnectionGeneration()
+
         override fun onServerDiscovered(name: String, address: String) {
             Log.d(TAG, "Server discovered (ignored in service): $name at $address")
         }
 
-        override fun onStateChanged(state: String) {
-            mainHandler.post {
+        override fun onStateChanged(state: PlaybackStateType) {
+            postForCurrentConnection("state change") {
                 Log.d(TAG, "State changed: $state")
-                val newState = PlaybackStateType.fromString(state)
 
                 // Handle playback state transitions per SendSpin spec
-                if (newState == PlaybackStateType.STOPPED) {
+                if (state == PlaybackStateType.STOPPED) {
                     // Check if we're in DRAINING state (actively playing from buffer during reconnection)
                     val isDraining = syncAudioPlayer?.getPlaybackState() == SyncPlaybackState.DRAINING
                     if (isDraining) {
@@ -1268,21 +1405,26 @@ class PlaybackService : MediaLibraryService() {
                         syncAudioPlayer?.pause()
                         releasePlaybackLocks()
                     }
-                } else if (newState == PlaybackStateType.PAUSED) {
+                } else if (state == PlaybackStateType.PAUSED) {
                     // Pause: "maintains current position for later resumption" - keep buffer
                     Log.d(TAG, "State is paused - pausing audio (keeping buffer)")
                     sendSpinPlayer?.updatePlayWhenReadyFromServer(false)
                     syncAudioPlayer?.pause()
+                    focusTracker.onPaused()
                     releasePlaybackLocks()
-                } else if (newState == PlaybackStateType.PLAYING) {
+                } else if (state == PlaybackStateType.PLAYING) {
                     // Playing: resume playback if paused
                     Log.d(TAG, "State is playing - resuming audio and acquiring playback locks")
                     sendSpinPlayer?.updatePlayWhenReadyFromServer(true)
-                    syncAudioPlayer?.resume()
+                    resumeLocalPlayback()
                     acquirePlaybackLocks()
                 }
 
-                _playbackState.value = _playbackState.value.copy(playbackState = newState)
+                // An unrecognized server value says nothing about what we're
+                // doing locally; keep the current state rather than guessing.
+                if (state != PlaybackStateType.UNKNOWN) {
+                    _playbackState.value = _playbackState.value.copy(playbackState = state)
+                }
 
                 // Complete deferred DRAINING exit after processing state
                 completePendingExitDraining()
@@ -1290,16 +1432,17 @@ class PlaybackService : MediaLibraryService() {
         }
 
         @OptIn(UnstableApi::class)
-        override fun onGroupUpdate(groupId: String, groupName: String, playbackState: String) {
-            mainHandler.post {
+        override fun onGroupUpdate(groupId: String, groupName: String, playbackState: PlaybackStateType?) {
+            postForCurrentConnection("group update") {
                 Log.d(TAG, "Group update: id=$groupId name=$groupName state=$playbackState")
 
                 val currentState = _playbackState.value
                 val isGroupChange = groupId.isNotEmpty() && groupId != currentState.groupId
-                val newPlaybackState = PlaybackStateType.fromString(playbackState)
+                // Absent or unrecognized: reconcile by keeping the local state.
+                val newPlaybackState = playbackState?.takeIf { it != PlaybackStateType.UNKNOWN }
 
                 // Handle playback state transitions per SendSpin spec
-                if (playbackState.isNotEmpty()) {
+                if (newPlaybackState != null) {
                     when (newPlaybackState) {
                         PlaybackStateType.STOPPED -> {
                             // Check if we're in DRAINING state (actively playing from buffer during reconnection)
@@ -1330,7 +1473,7 @@ class PlaybackService : MediaLibraryService() {
                             // Playing: resume playback if paused
                             Log.d(TAG, "Playback playing - resuming audio and acquiring playback locks")
                             sendSpinPlayer?.updatePlayWhenReadyFromServer(true)
-                            syncAudioPlayer?.resume()
+                            resumeLocalPlayback()
                             sendSpinPlayer?.setSyncAudioPlayer(syncAudioPlayer)
                             acquirePlaybackLocks()
                         }
@@ -1342,15 +1485,13 @@ class PlaybackService : MediaLibraryService() {
                     currentState.withClearedMetadata().copy(
                         groupId = groupId,
                         groupName = groupName.ifEmpty { null },
-                        playbackState = newPlaybackState
+                        playbackState = newPlaybackState ?: PlaybackStateType.IDLE
                     )
                 } else {
                     currentState.copy(
                         groupId = groupId.ifEmpty { currentState.groupId },
                         groupName = groupName.ifEmpty { currentState.groupName },
-                        playbackState = if (playbackState.isNotEmpty())
-                            newPlaybackState
-                        else currentState.playbackState
+                        playbackState = newPlaybackState ?: currentState.playbackState
                     )
                 }
                 _playbackState.value = newState
@@ -1359,7 +1500,7 @@ class PlaybackService : MediaLibraryService() {
                 broadcastSessionExtras()
 
                 // Complete deferred DRAINING exit after processing group state
-                if (playbackState.isNotEmpty()) {
+                if (newPlaybackState != null) {
                     completePendingExitDraining()
                 }
             }
@@ -1375,7 +1516,7 @@ class PlaybackService : MediaLibraryService() {
             positionMs: Long,
             playbackSpeed: Int
         ) {
-            mainHandler.post {
+            postForCurrentConnection("metadata update") {
                 Log.d(TAG, "Metadata update: $title / $artist / $album")
 
                 // In REMOTE mode, the server sends artwork URLs pointing to its own
@@ -1438,12 +1579,25 @@ class PlaybackService : MediaLibraryService() {
             }
         }
 
+        override fun onPositionUpdate(positionMs: Long) {
+            postForCurrentConnection("position update") {
+                // State only: the MediaSession player interpolates on its
+                // own, and controllers get extras on real state changes.
+                _playbackState.value = _playbackState.value.copy(
+                    positionMs = positionMs,
+                    positionUpdatedAt = SystemClock.elapsedRealtime()
+                )
+            }
+        }
+
         override fun onArtwork(imageData: ByteArray) {
             // Skip artwork processing in low memory mode
             if (com.sendspindroid.UserSettings.lowMemoryMode) {
                 return
             }
 
+            val client = sendSpinClient
+            val generation = client?.getConnectionGeneration()
             serviceScope.launch {
                 Log.d(TAG, "Artwork received: ${imageData.size} bytes")
                 try {
@@ -1451,6 +1605,11 @@ class PlaybackService : MediaLibraryService() {
                         val bitmap = BitmapFactory.decodeByteArray(imageData, 0, imageData.size)
                         bitmap?.let { scaleArtwork(it) }
                     }
+                    // The decode can outlast a reconnect; don't show the old server's image
+                    if (!isCurrentConnection(client, generation)) {
+                        Log.d(TAG, "Dropping artwork from superseded connection")
+                        return@launch
+                    }
                     if (scaled != null) {
                         binaryArtwork = scaled
                         // Only push to MediaSession if we don't already have URL-based
@@ -1466,7 +1625,7 @@ class PlaybackService : MediaLibraryService() {
         }
 
         override fun onArtworkCleared() {
-            mainHandler.post {
+            postForCurrentConnection("artwork clear") {
                 Log.d(TAG, "Artwork cleared by server (empty payload)")
                 binaryArtwork = null
                 updateMediaMetadata(
@@ -1486,6 +1645,8 @@ class PlaybackService : MediaLibraryService() {
             // will decode with the new decoder once the worker drains the
             // StartStream task ahead of it.
             decoderReady = true
+            serverOverflowBytesPerSec = -1L
+            audioStartedForStream = false
             serviceScope.launch {
                 decodeChannel.send(
                     DecodeTask.StartStream(codec, sampleRate, channels, bitDepth, codecHeader)
@@ -1540,6 +1701,12 @@ class PlaybackService : MediaLibraryService() {
                     ).apply {
                         // Set callback to update SendSpinPlayer when playback state changes
                         setStateCallback(SyncAudioPlayerStateCallback())
+                        setBalance(com.sendspindroid.UserSettings.balance)
+                        setMonoOutput(com.sendspindroid.UserSettings.monoOutput)
+                        setFreeRunning(
+                            com.sendspindroid.UserSettings.disableClockSync,
+                            com.sendspindroid.UserSettings.localBufferMs.toLong()
+                        )
                         initialize()
                         start()
                     }
@@ -1551,18 +1718,11 @@ class PlaybackService : MediaLibraryService() {
 
         override fun onStreamClear() {
             Log.i(TAG, "[cmd-trace] T2 onStreamClear ts=${System.nanoTime() / 1_000_000} thread=${Thread.currentThread().name}")
-            // Decoder flush goes through the channel so it is ordered with
-            // any in-flight Chunk tasks: every chunk enqueued before the
-            // stream/clear message decodes with the pre-flush decoder
-            // state; every chunk enqueued after decodes with the flushed
-            // decoder. Preserves the FIFO guarantee from the design.
-            serviceScope.launch { decodeChannel.send(DecodeTask.Flush) }
+            flushBuffers("Stream clear")
+        }
 
-            mainHandler.post {
-                Log.i(TAG, "[cmd-trace] T3 onStreamClear.post ts=${System.nanoTime() / 1_000_000} thread=${Thread.currentThread().name}")
-                Log.d(TAG, "Stream clear - flushing audio buffer")
-                syncAudioPlayer?.clearBuffer()
-            }
+        override fun onFlushBuffers() {
+            flushBuffers("User flush")
         }
 
         override fun onStreamEnd() {
@@ -1642,6 +1802,20 @@ class PlaybackService : MediaLibraryService() {
             }
         }
 
+        override fun onAudioStarted() {
+            audioStartedForStream = true
+        }
+
+        override fun onClockSyncUnstable(jitterMicros: Long, errorMicros: Long) {
+            Log.w(TAG, "Clock sync unstable: jitter=${jitterMicros}us error=${errorMicros}us")
+            clockSyncUnstableJitterUs = jitterMicros
+        }
+
+        override fun onServerOverflow(observedBytesPerSec: Long, advertisedBytesPerSec: Long) {
+            Log.w(TAG, "Server overflow: ${observedBytesPerSec} B/s vs ${advertisedBytesPerSec} B/s advertised")
+            serverOverflowBytesPerSec = observedBytesPerSec
+        }
+
         override fun onNetworkChanged() {
             mainHandler.post {
                 // Only clear buffer if NOT in DRAINING state
@@ -1969,6 +2143,49 @@ class PlaybackService : MediaLibraryService() {
         is FailureReason.Exhausted -> "Connection lost after multiple attempts"
     }
 
+    private fun scheduleApplyOutputCapabilities() {
+        mainHandler.removeCallbacks(applyOutputCapabilitiesRunnable)
+        mainHandler.postDelayed(applyOutputCapabilitiesRunnable, OUTPUT_DEVICE_DEBOUNCE_MS)
+    }
+
+    /**
+     * Reports what the current output device can play, so client/hello only
+     * advertises formats it supports. Prefers external outputs (USB DAC,
+     * Bluetooth, wired) over the built-in speaker, matching Android routing.
+     * Empty lists mean the device didn't report that dimension. Called before
+     * each connect and after output device changes; mid-stream, SendSpin asks
+     * the server to switch if the new device can't play the current format.
+     */
+    private fun applyOutputCapabilities() {
+        val client = sendSpinClient ?: return
+        val devices = audioManager?.getDevices(AudioManager.GET_DEVICES_OUTPUTS) ?: return
+        val priority = listOf(
+            setOf(AudioDeviceInfo.TYPE_USB_DEVICE, AudioDeviceInfo.TYPE_USB_HEADSET),
+            setOf(AudioDeviceInfo.TYPE_BLUETOOTH_A2DP),
+            setOf(AudioDeviceInfo.TYPE_WIRED_HEADPHONES, AudioDeviceInfo.TYPE_WIRED_HEADSET),
+            setOf(AudioDeviceInfo.TYPE_BUILTIN_SPEAKER)
+        )
+        val device = priority.firstNotNullOfOrNull { types -> devices.firstOrNull { it.type in types } }
+            ?: return
+
+        val bitDepths = device.encodings.mapNotNull { encoding ->
+            when {
+                encoding == AudioFormat.ENCODING_PCM_16BIT -> 16
+                Build.VERSION.SDK_INT >= Build.VERSION_CODES.S &&
+                    encoding == AudioFormat.ENCODING_PCM_24BIT_PACKED -> 24
+                Build.VERSION.SDK_INT >= Build.VERSION_CODES.S &&
+                    encoding == AudioFormat.ENCODING_PCM_32BIT -> 32
+                else -> null
+            }
+        }.distinct()
+        Log.d(TAG, "Output device: type=${device.type}, product=${device.productName}")
+        client.setOutputCapabilities(
+            sampleRates = device.sampleRates.toList(),
+            bitDepths = bitDepths,
+            channels = device.channelCounts.maxOrNull() ?: 0
+        )
+    }
+
     /**
      * Connects to a SendSpin server.
      *
@@ -2000,6 +2217,7 @@ class PlaybackService : MediaLibraryService() {
                 _playbackState.value = _playbackState.value.copy(volume = volumePercent)
             }
 
+            applyOutputCapabilities()
             sendSpinClient?.connect(SendSpinEndpoint.Local(address, path))
         } catch (e: Exception) {
             Log.e(TAG, "Error connecting to server", e)
@@ -2036,6 +2254,7 @@ class PlaybackService : MediaLibraryService() {
                 _playbackState.value = _playbackState.value.copy(volume = volumePercent)
             }
 
+            applyOutputCapabilities()
             sendSpinClient?.connect(SendSpinEndpoint.Remote(remoteId))
         } catch (e: Exception) {
             Log.e(TAG, "Error connecting to remote server", e)
@@ -2076,6 +2295,7 @@ class PlaybackService : MediaLibraryService() {
                 _playbackState.value = _playbackState.value.copy(volume = volumePercent)
             }
 
+            applyOutputCapabilities()
             sendSpinClient?.connect(SendSpinEndpoint.Proxy(url, authToken))
         } catch (e: Exception) {
             Log.e(TAG, "Error connecting to proxy server", e)
@@ -2514,6 +2734,10 @@ class PlaybackService : MediaLibraryService() {
      * Abandons audio focus when playback stops.
      */
     private fun abandonAudioFocus() {
+        // No GAIN will follow once we stop listening; don't leave resumes
+        // deferred or output ducked
+        focusTracker.reset()
+        syncAudioPlayer?.setDucked(false)
         if (!hasAudioFocus) return
 
         audioFocusRequest?.let { request ->
@@ -2532,9 +2756,16 @@ class PlaybackService : MediaLibraryService() {
     private fun handleAudioFocusChange(focusChange: Int) {
         when (focusChange) {
             AudioManager.AUDIOFOCUS_GAIN -> {
-                Log.d(TAG, "Audio focus gained")
-                // Focus returned - resume if we were playing before losing focus
-                syncAudioPlayer?.resume()
+                syncAudioPlayer?.setDucked(false)
+                val wasPlaying = focusTracker.wasPlayingBeforeLoss
+                // Resume only what the loss interrupted, and only if the server
+                // still has us playing (it may have paused during the call).
+                if (focusTracker.onGain(_playbackState.value.playbackState == PlaybackStateType.PLAYING)) {
+                    Log.d(TAG, "Audio focus gained - resuming interrupted playback")
+                    syncAudioPlayer?.resume()
+                } else {
+                    Log.d(TAG, "Audio focus gained - nothing to resume (wasPlaying=$wasPlaying)")
+                }
             }
             AudioManager.AUDIOFOCUS_LOSS -> {
                 Log.d(TAG, "Audio focus lost permanently")
@@ -2543,22 +2774,65 @@ class PlaybackService : MediaLibraryService() {
                 // client in a solo group and ends its streams; pressing play
                 // in our UI re-requests focus, which clears the state.
                 hasAudioFocus = false
+                focusTracker.onPermanentLoss()
                 syncAudioPlayer?.pause()
                 sendSpinClient?.setExternalSource(true)
             }
             AudioManager.AUDIOFOCUS_LOSS_TRANSIENT -> {
-                Log.d(TAG, "Audio focus lost transiently")
                 // Temporary loss (phone call, navigation announcement) - pause
+                // and remember whether there is anything to resume afterwards
+                focusTracker.onTransientLoss(_playbackState.value.playbackState == PlaybackStateType.PLAYING)
+                Log.d(TAG, "Audio focus lost transiently (wasPlaying=${focusTracker.wasPlayingBeforeLoss})")
                 syncAudioPlayer?.pause()
             }
             AudioManager.AUDIOFOCUS_LOSS_TRANSIENT_CAN_DUCK -> {
-                Log.d(TAG, "Audio focus lost transiently (can duck)")
-                // Could duck volume here, but for synced playback just let it play
-                // since ducking would desync volume with other clients
+                // Usually the system ducks us itself (API 26+ with
+                // setWillPauseWhenDucked(false)) and this isn't delivered; when
+                // it is, duck locally. Track gain only: timing keeps running and
+                // the server's volume for this player doesn't change.
+                Log.d(TAG, "Audio focus lost transiently (can duck) - ducking locally")
+                syncAudioPlayer?.setDucked(true)
             }
         }
     }
 
+    /**
+     * Drop everything buffered between the socket and the speaker: queued
+     * and decoded PCM, jitter buffer, and decoder state. Used for server
+     * stream/clear and as a user-triggered recovery action for a stuck or
+     * garbled stream. Safe while streaming: the connection stays up and the
+     * player re-anchors on the next chunk that arrives.
+     *
+     * Callable from any thread.
+     */
+    private fun flushBuffers(reason: String) {
+        // Decoder flush goes through the channel so it is ordered with
+        // any in-flight Chunk tasks: every chunk enqueued before the
+        // flush decodes with the pre-flush decoder state; every chunk
+        // enqueued after decodes with the flushed decoder. Preserves the
+        // FIFO guarantee from the design.
+        serviceScope.launch { decodeChannel.send(DecodeTask.Flush) }
+
+        mainHandler.post {
+            Log.i(TAG, "[cmd-trace] T3 flushBuffers.post ts=${System.nanoTime() / 1_000_000} thread=${Thread.currentThread().name}")
+            Log.d(TAG, "$reason - flushing audio buffer")
+            syncAudioPlayer?.clearBuffer()
+        }
+    }
+
+    /**
+     * Resume local audio for a server "playing" state, unless a transient
+     * focus loss is in progress: then just note it, and AUDIOFOCUS_GAIN
+     * resumes. Keeps a remote play from talking over a phone call.
+     */
+    private fun resumeLocalPlayback() {
+        if (focusTracker.onPlayRequested()) {
+            Log.d(TAG, "Deferring resume until audio focus returns")
+            return
+        }
+        syncAudioPlayer?.resume()
+    }
+
     /**
      * Register a receiver for ACTION_AUDIO_BECOMING_NOISY so we pause when the
      * audio output device disconnects. Idempotent.
@@ -3008,6 +3282,8 @@ class PlaybackService : MediaLibraryService() {
                 .add(SessionCommand(COMMAND_PREVIOUS, Bundle.EMPTY))
                 .add(SessionCommand(COMMAND_SWITCH_GROUP, Bundle.EMPTY))
                 .add(SessionCommand(COMMAND_GET_STATS, Bundle.EMPTY))
+                .add(SessionCommand(COMMAND_GET_METRICS, Bundle.EMPTY))
+                .add(SessionCommand(COMMAND_FLUSH_BUFFERS, Bundle.EMPTY))
                 .build()
 
             // Player commands must include SET_MEDIA_ITEM so the legacy compat bridge
@@ -3148,6 +3424,24 @@ class PlaybackService : MediaLibraryService() {
                     Futures.immediateFuture(SessionResult(SessionResult.RESULT_SUCCESS, statsBundle))
                 }
 
+                COMMAND_GET_METRICS -> {
+                    // Same counters as GET_STATS, as OpenMetrics text for scraping
+                    val statsBundle = getStats()
+                    @Suppress("DEPRECATION")
+                    val metrics = MetricsText.format { key -> statsBundle.get(key) }
+                    val result = Bundle().apply { putString(EXTRA_METRICS_TEXT, metrics) }
+                    Futures.immediateFuture(SessionResult(SessionResult.RESULT_SUCCESS, result))
+                }
+
+                COMMAND_FLUSH_BUFFERS -> {
+                    Log.i(TAG, "Flush buffers command received")
+                    // Through the client so protocol-side state resets too;
+                    // it calls back into onFlushBuffers for the local flush.
+                    val client = sendSpinClient
+                    if (client != null) client.flushBuffers() else flushBuffers("User flush")
+                    Futures.immediateFuture(SessionResult(SessionResult.RESULT_SUCCESS))
+                }
+
                 else -> {
                     Log.w(TAG, "Unknown custom command: ${customCommand.customAction}")
                     super.onCustomCommand(session, controller, customCommand, args)
@@ -3270,12 +3564,34 @@ class PlaybackService : MediaLibraryService() {
             bundle.putLong("last_byte_received_ago_ms", client.getLastByteReceivedAgoMs())
             bundle.putBoolean("stall_watchdog_armed", client.isStallWatchdogArmed())
             bundle.putInt("reconnect_attempts_total", client.getReconnectAttemptsTotal())
+            bundle.putLong("bytes_received_total", client.getBytesReceivedTotal())
+            client.getLastError()?.let { error ->
+                bundle.putString("last_error", error.message)
+                bundle.putLong("last_error_at_ms", error.atMs)
+            }
+            client.getLastRttMicros().takeIf { it >= 0 }?.let { bundle.putLong("rtt_us", it) }
             client.getLastDisconnectCode()?.let { bundle.putInt("last_disconnect_code", it) }
             client.getLastDisconnectReason()?.let { bundle.putString("last_disconnect_reason", it) }
             bundle.putDouble("time_filter_stability", timeFilter.stability)
+            if (timeFilter.isConverged) clockSyncUnstableJitterUs = -1L
+            clockSyncUnstableJitterUs.takeIf { it >= 0 }?.let { bundle.putLong("clock_sync_unstable_jitter_us", it) }
+            serverOverflowBytesPerSec.takeIf { it >= 0 }?.let { bundle.putLong("server_overflow_bps", it) }
+            bundle.putBoolean("audio_started", audioStartedForStream)
             bundle.putLong("time_filter_convergence_ms", timeFilter.convergenceTimeMillis)
+            // Newline-delimited "task=status" lines for the TASKS section.
+            bundle.putString(
+                "task_status",
+                client.getTaskStatus().entries.joinToString("\n") { "${it.key}=${it.value}" }
+            )
         }
 
+        // Effective configuration (input side of a bug report), same line format
+        val config = (sendSpinClient?.getConfigSnapshot() ?: emptyMap()) + mapOf(
+            "balance" to com.sendspindroid.UserSettings.balance.toString(),
+            "mono_output" to com.sendspindroid.UserSettings.monoOutput.toString(),
+        )
+        bundle.putString("config", config.entries.joinToString("\n") { "${it.key}=${it.value}" })
+
         // Get network stats from NetworkEvaluator
         networkEvaluator?.networkState?.value?.let { netState ->
             bundle.putString("network_type", netState.transportType.name)
@@ -4155,6 +4471,10 @@ class PlaybackService : MediaLibraryService() {
         // Unregister High Power Mode receiver and release locks
         LocalBroadcastManager.getInstance(this).unregisterReceiver(highPowerModeReceiver)
         LocalBroadcastManager.getInstance(this).unregisterReceiver(preferredCodecReceiver)
+        LocalBroadcastManager.getInstance(this).unregisterReceiver(balanceReceiver)
+        LocalBroadcastManager.getInstance(this).unregisterReceiver(monoOutputReceiver)
+        LocalBroadcastManager.getInstance(this).unregisterReceiver(clockSyncReceiver)
+        LocalBroadcastManager.getInstance(this).unregisterReceiver(localBufferReceiver)
         releaseHighPowerLocks()
 
         // Unregister the becoming-noisy receiver (system broadcast)
@@ -4169,6 +4489,8 @@ class PlaybackService : MediaLibraryService() {
             volumeObserverRegistered = false
         }
         volumeObserver = null
+        audioManager?.unregisterAudioDeviceCallback(outputDeviceCallback)
+        mainHandler.removeCallbacks(applyOutputCapabilitiesRunnable)
 
         // Stop browse discovery if running
         browseDiscoveryManager?.cleanup()
@@ -5,7 +5,9 @@ import android.os.Build
 import android.util.Log
 import com.sendspindroid.UserSettings
 import com.sendspindroid.logging.AppLog
+import com.sendspindroid.model.PlaybackStateType
 import com.sendspindroid.remote.WebRTCTransport
+import com.sendspindroid.sendspin.transport.BaseWebSocketTransport
 import com.sendspindroid.sendspin.transport.ProxyWebSocketTransport
 import com.sendspindroid.sendspin.protocol.ControllerState
 import com.sendspindroid.sendspin.protocol.GroupInfo
@@ -28,11 +30,20 @@ import kotlinx.coroutines.delay
 import kotlinx.coroutines.flow.MutableStateFlow
 import kotlinx.coroutines.flow.StateFlow
 import kotlinx.coroutines.flow.asStateFlow
+import kotlinx.coroutines.flow.collectLatest
+import kotlinx.coroutines.flow.combine
+import kotlinx.coroutines.flow.distinctUntilChanged
+import kotlinx.coroutines.flow.first
+import kotlinx.coroutines.flow.map
+import kotlinx.coroutines.flow.update
+import kotlinx.coroutines.isActive
 import kotlinx.coroutines.launch
 import kotlinx.coroutines.withContext
+import kotlinx.coroutines.withTimeoutOrNull
 import java.util.concurrent.Executors
 import com.sendspindroid.sendspin.decoder.AudioDecoderFactory
 import com.sendspindroid.sendspin.protocol.message.MessageBuilder
+import com.sendspindroid.sendspin.protocol.timesync.TimeSyncManager
 import kotlinx.serialization.json.Json
 import kotlinx.serialization.json.JsonPrimitive
 import kotlinx.serialization.json.buildJsonObject
@@ -81,6 +92,12 @@ class SendSpin(
     companion object {
         private const val TAG = "SendSpin"
 
+        // Default wait in sendCommandAndAwait() for the server to reflect a command.
+        const val COMMAND_ACK_TIMEOUT_MS = 3_000L
+
+        // Floor for setPositionUpdateInterval(), so a typo can't spin the timer thread.
+        const val MIN_POSITION_UPDATE_INTERVAL_MS = 20L
+
         // Reconnection configuration
         // Short initial delay (500ms) to maximize reconnect attempts during buffer drain
         // Sequence: 500ms, 1s, 2s, 4s, 8s - gives ~5 attempts in first 15 seconds
@@ -110,6 +127,12 @@ class SendSpin(
         // Issue #127.
         private const val IDLE_STALL_TIMEOUT_MS = 20_000L
 
+        // How long to wait for server/hello (and auth-ack in PROXY mode) after the
+        // transport opens. The stall watchdog only runs post-handshake, so without
+        // this a server that accepts the socket and never answers would leave us
+        // in Connecting forever.
+        private const val HANDSHAKE_TIMEOUT_MS = 10_000L
+
         // After this many consecutive LOCAL-mode reconnect failures in a row, switch
         // internally to PROXY if a fallback was configured via setProxyFallback().
         // Prevents indefinite retry of a dead LAN address when the server is no longer
@@ -123,8 +146,9 @@ class SendSpin(
      */
     interface Callback {
         fun onServerDiscovered(name: String, address: String)
-        fun onStateChanged(state: String)
-        fun onGroupUpdate(groupId: String, groupName: String, playbackState: String)
+        fun onStateChanged(state: PlaybackStateType)
+        /** [playbackState] is null when the update didn't carry one. */
+        fun onGroupUpdate(groupId: String, groupName: String, playbackState: PlaybackStateType?)
         fun onMetadataUpdate(
             title: String,
             artist: String,
@@ -139,6 +163,14 @@ class SendSpin(
         fun onStreamStart(codec: String, sampleRate: Int, channels: Int, bitDepth: Int, codecHeader: ByteArray?)
         fun onStreamClear()
         fun onStreamEnd()
+
+        /**
+         * Local flush from [flushBuffers]: drop buffered audio and decoder
+         * state. The stream keeps running, so audio that follows plays as
+         * normal. Defaults to [onStreamClear], which does the same app-side
+         * work.
+         */
+        fun onFlushBuffers() = onStreamClear()
         fun onAudioChunk(serverTimeMicros: Long, audioData: ByteArray)
         fun onVolumeChanged(volume: Int)
         fun onMutedChanged(muted: Boolean)
@@ -153,6 +185,39 @@ class SendSpin(
          * that don't render audio.
          */
         fun onSyncMuteChanged(muted: Boolean) {}
+
+        /**
+         * Called when the first audio chunk arrives after a stream/start,
+         * once per stream. Distinct from [onStateChanged]: the server can
+         * report "playing" well before (or without) any audio reaching us,
+         * so a missing call here after [onStreamStart] is the "shows
+         * playing but silent" case. Default no-op.
+         */
+        fun onAudioStarted() {}
+
+        /**
+         * Periodic interpolated track position while playing, in ms. Only
+         * fires after [setPositionUpdateInterval] enables it, and runs on a
+         * background thread. Default no-op.
+         */
+        fun onPositionUpdate(positionMs: Long) {}
+
+        /**
+         * Clock sync has not converged after a sustained period, so
+         * multi-room alignment will stay off until the network improves.
+         * Fires once per episode; [jitterMicros] is the measured RTT
+         * jitter and [errorMicros] the remaining offset uncertainty.
+         * Default no-op.
+         */
+        fun onClockSyncUnstable(jitterMicros: Long, errorMicros: Long) {}
+
+        /**
+         * The server is sending audio faster than our advertised buffer
+         * capacity allows, so chunk drops are the server ignoring flow
+         * control rather than this device falling behind. Fires once per
+         * stream; rates are in wire bytes/sec. Default no-op.
+         */
+        fun onServerOverflow(observedBytesPerSec: Long, advertisedBytesPerSec: Long) {}
     }
 
     /**
@@ -164,6 +229,12 @@ class SendSpin(
         PROXY    // WebSocket via authenticated reverse proxy
     }
 
+    /**
+     * The most recent connection failure, kept so a UI that attaches after
+     * the fact can still show why. [atMs] is wall-clock time.
+     */
+    data class LastError(val reason: FailureReason, val message: String, val atMs: Long)
+
     // Dedicated single-thread dispatcher for timer-dominated work: stall
     // watchdog polling, reconnect backoff delays, TimeSyncManager's
     // periodic scheduler. Isolating this from Dispatchers.IO means timer
@@ -197,14 +268,152 @@ class SendSpin(
     @Volatile
     var selfReconnectEnabled: Boolean = true
 
+    // Wait for server/hello after the transport opens, in ms.
+    // `internal var` (not a constant) solely so unit tests can shorten it;
+    // production code never reassigns it.
+    @Volatile
+    internal var handshakeTimeoutMs: Long = HANDSHAKE_TIMEOUT_MS
+
+    /**
+     * Largest WebSocket frame passed on to the protocol handler, in bytes;
+     * a bigger frame ends the session with 1009. Checked after the frame
+     * is received (see [BaseWebSocketTransport]), so it limits what the app
+     * does with garbage, not what the socket reads. Applies from the next
+     * connect. Zero or negative resets to the default.
+     */
+    @Volatile
+    var maxFrameBytes: Int = BaseWebSocketTransport.DEFAULT_MAX_FRAME_BYTES
+        set(value) {
+            field = if (value > 0) {
+                value
+            } else {
+                Log.w(TAG, "Invalid maxFrameBytes $value, using default ${BaseWebSocketTransport.DEFAULT_MAX_FRAME_BYTES}")
+                BaseWebSocketTransport.DEFAULT_MAX_FRAME_BYTES
+            }
+        }
+
+    /**
+     * How often clock sync re-measures once converged, in ms. The fast
+     * schedule used until convergence is unaffected. See
+     * [TimeSyncManager.convergedIntervalMs] for the accuracy vs. traffic
+     * tradeoff. Zero or negative resets to the default; out-of-range values
+     * are clamped. Both are logged.
+     */
+    var clockSyncIntervalMs: Long
+        get() = timeSyncManager?.convergedIntervalMs ?: TimeSyncManager.DEFAULT_CONVERGED_INTERVAL_MS
+        set(value) {
+            val interval = if (value > 0) {
+                value
+            } else {
+                Log.w(TAG, "Invalid clockSyncIntervalMs $value, using default ${TimeSyncManager.DEFAULT_CONVERGED_INTERVAL_MS}")
+                TimeSyncManager.DEFAULT_CONVERGED_INTERVAL_MS
+            }
+            val clamped = interval.coerceIn(
+                TimeSyncManager.MIN_CONVERGED_INTERVAL_MS,
+                TimeSyncManager.MAX_CONVERGED_INTERVAL_MS
+            )
+            if (clamped != interval) {
+                Log.w(TAG, "clockSyncIntervalMs $interval out of range, using $clamped")
+            }
+            timeSyncManager?.convergedIntervalMs = clamped
+        }
+
+    /**
+     * Turn off clock sync measurement for single-device listening. Time
+     * sync drops to a slow keepalive (its replies are what the idle stall
+     * watchdog watches) and the filter stops updating. Pair with
+     * [SyncAudioPlayer.setFreeRunning] so playback stops following server
+     * timestamps. Breaks multi-room alignment with other players.
+     */
+    var clockSyncDisabled: Boolean
+        get() = timeSyncManager?.keepaliveOnly ?: false
+        set(value) {
+            timeSyncManager?.keepaliveOnly = value
+            Log.i(TAG, "Clock sync ${if (value) "disabled" else "enabled"}")
+            // The reported sync state no longer follows the filter while off
+            evaluateAndPublishSyncState()
+        }
+
+    // What the current output device can play, from setOutputCapabilities();
+    // null until the app reports it, meaning every decodable format.
+    private class OutputCapabilities(val sampleRates: List<Int>, val bitDepths: List<Int>, val channels: Int)
+
+    @Volatile
+    private var outputCapabilities: OutputCapabilities? = null
+
+    /**
+     * Tell the player what the current output device (speaker, Bluetooth,
+     * USB DAC) supports, so the formats advertised in client/hello only
+     * include ones it can play. Empty lists or `channels <= 0` mean "not
+     * reported" for that dimension (see [MessageBuilder.filterFormatsForOutput]).
+     *
+     * The advertised list takes effect on the next handshake. If a stream is
+     * already running in a format the device can't play, this also asks the
+     * server to switch via stream/request-format, keeping the codec when a
+     * compatible entry for it exists, so no reconnect is needed.
+     */
+    fun setOutputCapabilities(sampleRates: List<Int>, bitDepths: List<Int>, channels: Int) {
+        val rates = sampleRates.filter { it > 0 }
+        val depths = bitDepths.filter { it > 0 }
+        if (rates.size != sampleRates.size || depths.size != bitDepths.size) {
+            Log.w(TAG, "Ignoring non-positive output capabilities: rates=$sampleRates, bits=$bitDepths")
+        }
+        outputCapabilities = OutputCapabilities(rates, depths, channels)
+        Log.i(TAG, "Output capabilities: rates=$rates, bits=$depths, ch=$channels")
+
+        val stream = currentStreamConfig ?: return
+        val formats = getSupportedFormats()
+        val fits = formats.any {
+            it.codec == stream.codec && it.sampleRate == stream.sampleRate &&
+                it.channels == stream.channels && it.bitDepth == stream.bitDepth
+        }
+        if (fits) return
+        val target = formats.firstOrNull { it.codec == stream.codec } ?: formats.firstOrNull() ?: return
+        Log.i(TAG, "Active stream ${stream.sampleRate}/${stream.channels}ch/${stream.bitDepth}bit not supported by output")
+        requestStreamFormat(
+            codec = target.codec,
+            sampleRate = target.sampleRate,
+            channels = target.channels,
+            bitDepth = target.bitDepth
+        )
+    }
+
     // Merged controller (group-level) state: supported_commands, group
     // volume/mute, repeat, shuffle. Null until the server first sends a
     // server/state controller object.
     private val _controllerState = MutableStateFlow<ControllerState?>(null)
     val controllerState: StateFlow<ControllerState?> = _controllerState.asStateFlow()
 
+    // What server/state and group/update have reported, for sendCommandAndAwait().
+    // groupSeq lets "switch" wait for a fresh group/update rather than a value.
+    private data class CommandObservation(
+        val playbackState: PlaybackStateType? = null,
+        val metadata: TrackMetadata? = null,
+        val groupSeq: Long = 0
+    )
+    private val commandObservation = MutableStateFlow(CommandObservation())
+
+    // Opt-in onPositionUpdate ticker; see setPositionUpdateInterval().
+    // 0 = off. The job only exists between a completed handshake and the
+    // next disconnect.
+    @Volatile
+    private var positionUpdateIntervalMs = 0L
+    @Volatile
+    private var positionTickerJob: Job? = null
+
+    // Scope the position ticker runs in.
+    // `internal var` (not `private val`) solely so unit tests can substitute a
+    // test dispatcher; production code never reassigns it.
+    internal var positionTickerScope: CoroutineScope = timerScope
+
     // Transport abstraction - can be WebSocket (local) or WebRTC (remote)
     private var transport: SendSpinTransport? = null
+
+    // Bumped for every new TransportEventListener and on disconnect. A listener
+    // only acts while its generation is current, so an event already in flight
+    // from a replaced transport (rapid connect/disconnect/connect) is dropped
+    // instead of driving the new connection's state.
+    private val connectionGeneration = AtomicLong(0)
     private var connectionMode: ConnectionMode = ConnectionMode.LOCAL
 
     // Connection info (stored for reconnection)
@@ -254,21 +463,33 @@ class SendSpin(
     @Volatile
     private var stallWatchdogJob: Job? = null
 
+    // Pending server/hello deadline, armed when the transport opens. Shares
+    // watchdogLock with the stall watchdog.
+    @Volatile
+    private var handshakeTimeoutJob: Job? = null
+
     // True while a server-announced audio stream is active. The stall watchdog
     // only trips while streaming - during idle (no stream) the server may send
     // nothing for long periods, which would cause false-positive stalls.
     private val streamActive = AtomicBoolean(false)
 
+    // Set by stream/start, cleared by the first audio chunk that follows it
+    // (firing onAudioStarted) or by stream/end.
+    private val awaitingFirstAudio = AtomicBoolean(false)
+
     // -- Connection health telemetry (issue #128). All observational: updated on
     // event paths that already touch state (handshake-complete, onClosed,
     // onFailure, attemptReconnect); read by the stats poll and the structured
     // [disconnect]/[reconnect-ok] log lines. No hot-path cost.
     private val reconnectAttemptsTotal = AtomicInteger(0)
+    private val bytesReceivedTotal = AtomicLong(0)
     @Volatile private var connectedAtMs: Long? = null
     @Volatile private var lastDisconnectAtMs: Long? = null
     @Volatile private var lastDisconnectCode: Int? = null
     @Volatile private var lastDisconnectReason: String? = null
     @Volatile private var lastDisconnectMode: ConnectionMode? = null
+    // Sticky until the next successful handshake; see [getLastError].
+    @Volatile private var lastError: LastError? = null
 
     val isConnected: Boolean
         get() = _connectionState.value is TransportState.Ready
@@ -298,6 +519,90 @@ class SendSpin(
     /** Lifetime reconnect attempts (survives across sessions within the process). */
     fun getReconnectAttemptsTotal(): Int = reconnectAttemptsTotal.get()
 
+    /**
+     * Lifetime payload bytes received from the server across sessions. Text
+     * frames are counted by character length, which equals bytes for the
+     * ASCII JSON the protocol sends.
+     */
+    fun getBytesReceivedTotal(): Long = bytesReceivedTotal.get()
+
+    /**
+     * The last connection failure and when it happened, or null if the
+     * client has connected successfully since. Unlike [connectionState],
+     * this survives the retry loop moving on to Connecting again.
+     */
+    fun getLastError(): LastError? = lastError
+
+    private fun recordError(reason: FailureReason, message: String) {
+        lastError = LastError(reason, message, System.currentTimeMillis())
+    }
+
+    /**
+     * Generation of the current connection; changes on every connect,
+     * reconnect, and disconnect. Callers that hop threads before applying a
+     * callback compare it to drop events from a superseded connection.
+     */
+    fun getConnectionGeneration(): Long = connectionGeneration.get()
+
+    /**
+     * What each background task is doing right now, for the stats screen.
+     * Answers "is anything still alive?" on a stuck-connecting report:
+     * whether the transport is open, the handshake deadline is pending,
+     * the stall watchdog and time-sync loop are running, or a reconnect is
+     * waiting out its backoff. Keys are stable; values are short lowercase
+     * words ("running", "none", "cancelled", "done", or a transport state).
+     */
+    fun getTaskStatus(): Map<String, String> = linkedMapOf(
+        "transport" to (transport?.state?.name?.lowercase() ?: "none"),
+        "handshake_timeout" to handshakeTimeoutJob.statusName(),
+        "stall_watchdog" to stallWatchdogJob.statusName(),
+        "reconnect" to reconnectJob.statusName(),
+        "position_ticker" to positionTickerJob.statusName(),
+        "time_sync" to if (timeSyncManager?.isRunning == true) "running" else "stopped",
+        "scopes" to if (timerScope.isActive && workScope.isActive) "active" else "cancelled",
+    )
+
+    /**
+     * The effective client configuration, for bug reports: every option with
+     * its resolved value, as key -> value. Addresses and secrets are left
+     * out; an auth token is only reported as set or not.
+     */
+    fun getConfigSnapshot(): Map<String, String> {
+        val formats = getSupportedFormats()
+        return linkedMapOf(
+            "connection_mode" to connectionMode.name.lowercase(),
+            "auth_token" to if (authToken != null) "[redacted]" else "none",
+            "proxy_fallback" to if (proxyFallbackUrl != null) "configured" else "none",
+            "roles" to supportedRoles().joinToString(","),
+            "preferred_codec" to UserSettings.getPreferredCodec(),
+            "supported_formats" to formats.joinToString(",") {
+                "${it.codec}/${it.sampleRate}/${it.channels}ch/${it.bitDepth}bit"
+            },
+            "buffer_capacity_bytes" to bufferCapacity(formats).toString(),
+            "low_memory_mode" to UserSettings.lowMemoryMode.toString(),
+            "high_power_mode" to UserSettings.highPowerMode.toString(),
+            "sync_offset_ms" to UserSettings.getSyncOffsetMs().toString(),
+            "clock_sync_interval_ms" to clockSyncIntervalMs.toString(),
+            "clock_sync_disabled" to clockSyncDisabled.toString(),
+            "handshake_timeout_ms" to handshakeTimeoutMs.toString(),
+            "stall_timeout_ms" to "$STALL_TIMEOUT_MS (idle $IDLE_STALL_TIMEOUT_MS)",
+            "reconnect_delay_ms" to "$INITIAL_RECONNECT_DELAY_MS..$MAX_RECONNECT_DELAY_MS",
+            "reconnect_attempts_max" to "$MAX_RECONNECT_ATTEMPTS (total $MAX_TOTAL_RECONNECT_ATTEMPTS)",
+            "self_reconnect" to selfReconnectEnabled.toString(),
+            "queue_commands_while_connecting" to queueCommandsWhileConnecting.toString(),
+            "max_artwork_bytes" to maxArtworkBytes.toString(),
+            "max_frame_bytes" to maxFrameBytes.toString(),
+            "sniff_mislabeled_binary" to sniffMislabeledBinary.toString(),
+        )
+    }
+
+    private fun Job?.statusName(): String = when {
+        this == null -> "none"
+        isActive -> "running"
+        isCancelled -> "cancelled"
+        else -> "done"
+    }
+
     /** Most recent close code seen on an abnormal disconnect; null if none. */
     fun getLastDisconnectCode(): Int? = lastDisconnectCode
 
@@ -322,6 +627,8 @@ class SendSpin(
         }
     }
 
+    override fun isConnecting(): Boolean = _connectionState.value == TransportState.Connecting
+
     // TimeSyncManager uses this scope for its periodic scheduler loop
     // (delay then send a small time-sync request). That is timer-dominated
     // work, so it belongs on timerScope.
@@ -345,20 +652,24 @@ class SendSpin(
         } else {
             AudioDecoderFactory.getSupportedPcmBitDepths()
         }
-        return MessageBuilder.buildSupportedFormats(
+        val formats = MessageBuilder.buildSupportedFormats(
             preferredCodec = UserSettings.getPreferredCodec(),
             isCodecSupported = { AudioDecoderFactory.isCodecSupported(it) },
             supportedBitDepths = bitDepths
         )
+        val output = outputCapabilities ?: return formats
+        return MessageBuilder.filterFormatsForOutput(formats, output.sampleRates, output.bitDepths, output.channels)
     }
 
     override fun onHandshakeComplete(serverName: String, serverId: String) {
+        stopHandshakeTimeout()
         this.serverName = serverName
         this.serverId = serverId
 
         // Controller state belongs to the previous session; the handler's
         // merged copy was reset, so reset the published flow too.
         _controllerState.value = null
+        commandObservation.update { it.copy(playbackState = null) }
 
         // Check if this is a reconnection
         val wasReconnecting = timeFilter.isFrozen || reconnecting.get()
@@ -390,6 +701,7 @@ class SendSpin(
         // Issue #128.
         connectedAtMs = System.currentTimeMillis()
         lastDisconnectAtMs = null
+        lastError = null
 
         if (wasReconnecting) {
             // Emit a structured recovery log line so shared on-device logs show
@@ -410,6 +722,7 @@ class SendSpin(
 
         streamActive.set(false)  // fresh handshake - wait for server to announce stream state
         startStallWatchdog()  // (re)start watchdog now that we have a live handshake-complete session
+        startPositionTicker()
     }
 
     override fun onMetadataUpdate(metadata: TrackMetadata) {
@@ -419,23 +732,27 @@ class SendSpin(
         // snapshot sat on the server (and downstream anchors interpolate
         // from receive time). Requires a converged clock; fall back to the
         // raw value until then.
-        val positionMs = if (timeFilter.isReady) {
-            metadata.progressAtServerTime(timeFilter.clientToServer(System.nanoTime() / 1000))
-        } else {
-            metadata.positionMs
-        }
         callback.onMetadataUpdate(
             metadata.title,
             metadata.artist,
             metadata.album,
             metadata.artworkUrl,
             metadata.durationMs,
-            positionMs,
+            currentPositionMs(metadata),
             metadata.progress.playbackSpeed
         )
+        commandObservation.update { it.copy(metadata = metadata) }
     }
 
-    override fun onPlaybackStateChanged(state: String) {
+    private fun currentPositionMs(metadata: TrackMetadata): Long =
+        if (timeFilter.isReady) {
+            metadata.progressAtServerTime(timeFilter.clientToServer(System.nanoTime() / 1000))
+        } else {
+            metadata.positionMs
+        }
+
+    override fun onPlaybackStateChanged(state: PlaybackStateType) {
+        commandObservation.update { it.copy(playbackState = state) }
         callback.onStateChanged(state)
     }
 
@@ -448,11 +765,15 @@ class SendSpin(
     }
 
     override fun onGroupUpdate(info: GroupInfo) {
+        commandObservation.update {
+            it.copy(playbackState = info.playbackState ?: it.playbackState, groupSeq = it.groupSeq + 1)
+        }
         callback.onGroupUpdate(info.groupId, info.groupName, info.playbackState)
     }
 
     override fun onStreamStart(config: StreamConfig) {
         streamActive.set(true)
+        awaitingFirstAudio.set(true)
         // Reset so we don't false-trip from any stale timestamp accumulated while
         // the stream was inactive (we were not expecting data then).
         lastByteReceivedAtMs.set(System.currentTimeMillis())
@@ -473,13 +794,23 @@ class SendSpin(
         callback.onStreamClear()
     }
 
+    // The stream is still active: keep the streaming stall threshold
+    override fun onFlushBuffers() {
+        callback.onFlushBuffers()
+    }
+
     override fun onStreamEnd() {
         streamActive.set(false)
+        awaitingFirstAudio.set(false)
         callback.onStreamEnd()
     }
 
     override fun onAudioChunk(timestampMicros: Long, audioData: ByteArray) {
         callback.onAudioChunk(timestampMicros, audioData)
+        if (awaitingFirstAudio.compareAndSet(true, false)) {
+            Log.i(TAG, "First audio chunk after stream start")
+            callback.onAudioStarted()
+        }
     }
 
     override fun onArtwork(channel: Int, payload: ByteArray) {
@@ -498,6 +829,14 @@ class SendSpin(
         callback.onSyncMuteChanged(muted)
     }
 
+    override fun onClockSyncUnstable(jitterMicros: Long, errorMicros: Long) {
+        callback.onClockSyncUnstable(jitterMicros, errorMicros)
+    }
+
+    override fun onServerOverflow(observedBytesPerSec: Long, advertisedBytesPerSec: Long) {
+        callback.onServerOverflow(observedBytesPerSec, advertisedBytesPerSec)
+    }
+
     override fun onControllerStateUpdate(state: ControllerState) {
         _controllerState.value = state
     }
@@ -571,6 +910,11 @@ class SendSpin(
             handshakeComplete = false
             stopTimeSync()
 
+            // Clean up old transport (the backoff path does the same)
+            transport?.setListener(null)
+            transport?.destroy()
+            transport = null
+
             when (connectionMode) {
                 ConnectionMode.LOCAL -> {
                     val savedAddress = serverAddress ?: return@launch
@@ -606,7 +950,12 @@ class SendSpin(
     }
 
     /**
-     * Connect to a SendSpin server on the local network.
+     * Connect to a SendSpin server by address.
+     *
+     * Discovery is not required: this client never runs mDNS itself, so any
+     * host reachable over plain WebSocket works, including over a VPN or a
+     * routed network where mDNS does not propagate. Reconnects re-dial the
+     * same address, and time sync and the stall watchdog run as usual.
      *
      * @param address Server address in "host:port" format
      * @param path WebSocket path (from mDNS TXT or default /sendspin)
@@ -730,10 +1079,42 @@ class SendSpin(
         createProxyTransport(url)
     }
 
+    /**
+     * Connect over a caller-supplied transport instead of dialing one, e.g. an
+     * in-memory transport in tests or a tunnel over a pre-authenticated socket.
+     *
+     * SendSpin takes ownership: it attaches its listener, calls
+     * [SendSpinTransport.connect], and closes the transport on disconnect.
+     * There is no automatic reconnect, since a transport SendSpin didn't
+     * create can't be re-dialed; a drop ends in Idle.
+     */
+    fun connectWithTransport(transport: SendSpinTransport) {
+        if (isConnected) {
+            Log.w(TAG, "Already connected, disconnecting first")
+            disconnect()
+        }
+
+        Log.d(TAG, "Connecting over caller-supplied transport")
+        prepareForConnection()
+
+        // LOCAL with no saved address: hasConnectionInfo() is false, so the
+        // reconnect paths stand down instead of dialing something else.
+        connectionMode = ConnectionMode.LOCAL
+        serverAddress = null
+        serverPath = null
+        remoteId = null
+        authToken = null
+
+        this.transport = transport
+        transport.setListener(TransportEventListener())
+        transport.connect()
+    }
+
     /**
      * Common preparation for both local and remote connections.
      */
     private fun prepareForConnection() {
+        stopHandshakeTimeout()
         _connectionState.value = TransportState.Connecting
         handshakeComplete = false
         awaitingAuthResponse = false
@@ -768,7 +1149,12 @@ class SendSpin(
      * Create and connect a local WebSocket transport.
      */
     private fun createLocalTransport(address: String, path: String) {
-        val wsTransport = WebSocketTransport(address, path, pingIntervalSeconds = getPingIntervalSeconds())
+        val wsTransport = WebSocketTransport(
+            address,
+            path,
+            pingIntervalSeconds = getPingIntervalSeconds(),
+            maxFrameBytes = maxFrameBytes
+        )
         transport = wsTransport
         wsTransport.setListener(TransportEventListener())
         wsTransport.connect()
@@ -792,7 +1178,8 @@ class SendSpin(
         val proxyTransport = ProxyWebSocketTransport(
             url = url,
             authToken = authToken,
-            pingIntervalSeconds = getPingIntervalSeconds()
+            pingIntervalSeconds = getPingIntervalSeconds(),
+            maxFrameBytes = maxFrameBytes
         )
         transport = proxyTransport
         proxyTransport.setListener(TransportEventListener())
@@ -848,6 +1235,8 @@ class SendSpin(
      */
     fun disconnectForReselection() {
         stopStallWatchdog()
+        stopPositionTicker()
+        stopHandshakeTimeout()
         Log.i(TAG, "Disconnecting for reselection (transport-type change)")
 
         // Cancel any pending reconnect coroutine to prevent races
@@ -863,6 +1252,8 @@ class SendSpin(
         sendGoodbye("restart")
         // Clear the transport listener BEFORE closing to prevent the async onClosed
         // callback from firing a second onDisconnected after we fire one synchronously below.
+        // Bumping the generation also drops any event the old listener is already handling.
+        connectionGeneration.incrementAndGet()
         transport?.setListener(null)
         transport?.close(1000, "Reselection")
         transport = null
@@ -875,6 +1266,8 @@ class SendSpin(
      */
     fun disconnect() {
         stopStallWatchdog()
+        stopPositionTicker()
+        stopHandshakeTimeout()
         Log.d(TAG, "Disconnecting (user-initiated)")
         userInitiatedDisconnect.set(true)
 
@@ -886,8 +1279,11 @@ class SendSpin(
         reconnecting.set(false)
         waitingForNetwork.set(false)
         sendGoodbye("user_request")
+        clearPendingCommands()
         // Clear the transport listener BEFORE closing to prevent the async onClosed
         // callback from firing a second onDisconnected after we fire one synchronously below.
+        // Bumping the generation also drops any event the old listener is already handling.
+        connectionGeneration.incrementAndGet()
         transport?.setListener(null)
         transport?.close(1000, "User disconnect")
         transport = null
@@ -895,6 +1291,150 @@ class SendSpin(
         _connectionState.value = TransportState.Idle
     }
 
+    /**
+     * Send a controller command and suspend until the server reflects it, or
+     * [timeoutMs] passes. Sendspin commands carry no request id, so the ack is
+     * the state the command should produce: play -> playing, volume -> group
+     * volume, repeat_one -> repeat "one", next/previous -> a new track,
+     * switch -> a group/update. A command whose target state already holds
+     * counts as acknowledged.
+     *
+     * Issued while connecting, the command is queued as usual and the wait
+     * covers the handshake too.
+     *
+     * @return true if the server confirmed in time; false if the command could
+     *   not be sent (not connected, unsupported, unknown) or went unconfirmed
+     */
+    suspend fun sendCommandAndAwait(
+        command: String,
+        volume: Int? = null,
+        mute: Boolean? = null,
+        timeoutMs: Long = COMMAND_ACK_TIMEOUT_MS
+    ): Boolean {
+        if (!isConnected && _connectionState.value != TransportState.Connecting) {
+            Log.w(TAG, "Not sending '$command': not connected")
+            return false
+        }
+        val supported = _controllerState.value?.supportedCommands
+        if (supported != null && command !in supported) {
+            Log.w(TAG, "Not sending '$command': not in server supported_commands $supported")
+            return false
+        }
+        val acknowledged = commandAck(command, volume, mute, commandObservation.value)
+        if (acknowledged == null) {
+            Log.w(TAG, "Not sending '$command': no known acknowledgement")
+            return false
+        }
+
+        sendCommand(command, volume = volume, mute = mute)
+        val confirmed = withTimeoutOrNull(timeoutMs) {
+            combine(commandObservation, _controllerState) { obs, ctrl -> acknowledged(obs, ctrl) }.first { it }
+        } ?: false
+        if (!confirmed) {
+            Log.w(TAG, "Server did not acknowledge '$command' within ${timeoutMs}ms")
+        }
+        return confirmed
+    }
+
+    /**
+     * Fire [Callback.onPositionUpdate] every [intervalMs] while the server
+     * reports playing, with the position extrapolated from the last
+     * server/state the same way onMetadataUpdate does. Pauses on any other
+     * state; the ticker stops on disconnect and restarts after the next
+     * handshake. Ticks are skipped until the time filter is ready (always,
+     * with [clockSyncDisabled]): without it the position can't be
+     * extrapolated and would just repeat the last reported value. 0 (the default) turns it off; calling again replaces the
+     * previous interval. Intervals below
+     * [MIN_POSITION_UPDATE_INTERVAL_MS] are raised to it; a negative
+     * interval falls back to the default (off). Both are logged.
+     */
+    fun setPositionUpdateInterval(intervalMs: Long) {
+        val requested = if (intervalMs >= 0) {
+            intervalMs
+        } else {
+            Log.w(TAG, "Invalid position update interval ${intervalMs}ms, using default (off)")
+            0L
+        }
+        val interval = if (requested > 0) requested.coerceAtLeast(MIN_POSITION_UPDATE_INTERVAL_MS) else 0L
+        if (interval != requested) {
+            Log.w(TAG, "Position update interval ${requested}ms too short, using ${interval}ms")
+        }
+        positionUpdateIntervalMs = interval
+        if (handshakeComplete) startPositionTicker() else stopPositionTicker()
+    }
+
+    /**
+     * (Re)start the position ticker at the current interval, or leave it
+     * stopped when the ticker is off. Called after the handshake completes.
+     * Serialized against [stopPositionTicker] via [watchdogLock].
+     */
+    private fun startPositionTicker() {
+        synchronized(watchdogLock) {
+            positionTickerJob?.cancel()
+            val interval = positionUpdateIntervalMs
+            positionTickerJob = if (interval > 0) {
+                positionTickerScope.launch { runPositionTicker(interval) }
+            } else {
+                null
+            }
+        }
+    }
+
+    /** Stop the position ticker. Called on every disconnect path. */
+    private fun stopPositionTicker() {
+        synchronized(watchdogLock) {
+            positionTickerJob?.cancel()
+            positionTickerJob = null
+        }
+    }
+
+    private suspend fun runPositionTicker(intervalMs: Long) {
+        commandObservation
+            .map { it.playbackState == PlaybackStateType.PLAYING }
+            .distinctUntilChanged()
+            .collectLatest { playing ->
+                while (playing) {
+                    val metadata = commandObservation.value.metadata
+                    if (isConnected && metadata != null && timeFilter.isReady) {
+                        callback.onPositionUpdate(currentPositionMs(metadata))
+                    }
+                    delay(intervalMs)
+                }
+            }
+    }
+
+    /** The state change that confirms [command], or null for commands we can't confirm. */
+    private fun commandAck(
+        command: String,
+        volume: Int?,
+        mute: Boolean?,
+        baseline: CommandObservation
+    ): ((CommandObservation, ControllerState?) -> Boolean)? = when (command) {
+        "play" -> { obs, _ -> obs.playbackState == PlaybackStateType.PLAYING }
+        "pause" -> { obs, _ -> obs.playbackState == PlaybackStateType.PAUSED }
+        "stop" -> { obs, _ ->
+            obs.playbackState == PlaybackStateType.STOPPED || obs.playbackState == PlaybackStateType.IDLE
+        }
+        "next" -> { obs, _ -> obs.metadata.trackKey() != baseline.metadata.trackKey() }
+        // Previous may restart the current track instead of changing it
+        "previous" -> { obs, _ ->
+            obs.metadata !== baseline.metadata && (
+                obs.metadata.trackKey() != baseline.metadata.trackKey() ||
+                    (obs.metadata?.positionMs ?: 0L) < (baseline.metadata?.positionMs ?: Long.MAX_VALUE)
+                )
+        }
+        "switch" -> { obs, _ -> obs.groupSeq > baseline.groupSeq }
+        "volume" -> { _, ctrl -> volume != null && ctrl?.volume == volume }
+        "mute" -> { _, ctrl -> mute != null && ctrl?.muted == mute }
+        "repeat_off", "repeat_one", "repeat_all" -> { _, ctrl -> ctrl?.repeat == command.removePrefix("repeat_") }
+        "shuffle" -> { _, ctrl -> ctrl?.shuffle == true }
+        "unshuffle" -> { _, ctrl -> ctrl?.shuffle == false }
+        else -> null
+    }
+
+    private fun TrackMetadata?.trackKey(): Triple<String, String, String>? =
+        this?.let { Triple(it.title, it.artist, it.album) }
+
     fun play() = sendCommand("play")
     fun pause() = sendCommand("pause")
     fun stop() = sendCommand("stop")
@@ -902,8 +1442,14 @@ class SendSpin(
     fun previous() = sendCommand("previous")
     fun switchGroup() = sendCommand("switch")
 
-    /** Set the volume of the whole group (0-100). */
-    fun setGroupVolume(volume: Int) = sendCommand("volume", volume = volume)
+    /** Set the volume of the whole group (0-100; out-of-range values are clamped). */
+    fun setGroupVolume(volume: Int) {
+        val clamped = volume.coerceIn(0, 100)
+        if (clamped != volume) {
+            Log.w(TAG, "Group volume $volume out of range, using $clamped")
+        }
+        sendCommand("volume", volume = clamped)
+    }
 
     /** Set the mute state of the whole group. */
     fun setGroupMute(muted: Boolean) = sendCommand("mute", mute = muted)
@@ -1010,6 +1556,80 @@ class SendSpin(
         }
     }
 
+    /**
+     * Arm the server/hello deadline. Called when the transport opens;
+     * cleared by [onHandshakeComplete] or any disconnect.
+     */
+    private fun startHandshakeTimeout() {
+        synchronized(watchdogLock) {
+            handshakeTimeoutJob?.cancel()
+            handshakeTimeoutJob = timerScope.launch {
+                delay(handshakeTimeoutMs)
+                onHandshakeTimeout()
+            }
+        }
+    }
+
+    private fun stopHandshakeTimeout() {
+        synchronized(watchdogLock) {
+            handshakeTimeoutJob?.cancel()
+            handshakeTimeoutJob = null
+        }
+    }
+
+    /**
+     * The server opened the transport but never completed the handshake.
+     * Close it ourselves and go through the same reconnect decision as an
+     * abnormal close. Done directly rather than via the transport's onClosed:
+     * a locally cancelled WebSocket does not report one.
+     *
+     * Private for production; reached via reflection from HandshakeFailurePathsTest.
+     */
+    private fun onHandshakeTimeout() {
+        if (handshakeComplete || userInitiatedDisconnect.get()) return
+        Log.w(TAG, "No server/hello within ${handshakeTimeoutMs}ms - abandoning connection")
+        recordError(FailureReason.HandshakeFailed, "No server/hello within ${handshakeTimeoutMs}ms")
+
+        recordDisconnectTelemetry(
+            code = 1001,
+            reasonText = "handshake timeout",
+            isNormalClosure = false,
+        )
+        transport?.close(1001, "Handshake timeout")
+        releaseFailedTransport()
+
+        if (selfReconnectEnabled && hasConnectionInfo()) {
+            attemptReconnect()
+        } else {
+            reconnecting.set(false)
+            _connectionState.value = TransportState.Idle
+        }
+    }
+
+    /**
+     * Tear down a transport whose connection ended before server/hello, so a
+     * failed attempt never leaves a socket, WebRTC peer or HTTP client behind
+     * and the next attempt starts from clean handshake state. Safe to call
+     * more than once.
+     */
+    private fun releaseFailedTransport() {
+        stopHandshakeTimeout()
+        handshakeComplete = false
+        awaitingAuthResponse = false
+        val t = transport ?: return
+        transport = null
+        // Detach first so destroy() can't feed events back into this client.
+        t.setListener(null)
+        t.destroy()
+    }
+
+    /** Whether we have what the current mode needs to reconnect. */
+    private fun hasConnectionInfo(): Boolean = when (connectionMode) {
+        ConnectionMode.LOCAL -> serverAddress != null
+        ConnectionMode.REMOTE -> remoteId != null
+        ConnectionMode.PROXY -> serverAddress != null && !authToken.isNullOrBlank()
+    }
+
     /**
      * Check whether the transport has gone silent for too long and force-close it
      * if so. Only acts when the client is connected, handshake is complete, and we
@@ -1132,6 +1752,7 @@ class SendSpin(
             reconnecting.set(false)
             reconnectJob?.cancel()
             reconnectJob = null
+            recordError(FailureReason.Exhausted, "Gave up after $prior reconnect attempts")
             _connectionState.value = TransportState.Failed(FailureReason.Exhausted)
             return
         }
@@ -1177,6 +1798,7 @@ class SendSpin(
             Log.i(TAG, "Time filter frozen for reconnection (had ${timeFilter.measurementCountValue} measurements)")
         }
         stopStallWatchdog()  // watchdog restarts on next successful handshake via onHandshakeComplete
+        stopPositionTicker()  // likewise the position ticker
 
         // If network is unavailable, pause without wasting an attempt
         // setNetworkAvailable(true) will resume via onNetworkAvailable()
@@ -1325,8 +1947,20 @@ class SendSpin(
      */
     private inner class TransportEventListener : SendSpinTransport.Listener {
 
+        private val generation = connectionGeneration.incrementAndGet()
+
+        /** True (and logged) when a newer connection or a disconnect superseded this one. */
+        private fun isStale(event: String): Boolean {
+            val current = connectionGeneration.get()
+            if (generation == current) return false
+            Log.d(TAG, "Ignoring $event from superseded connection (generation $generation, current $current)")
+            return true
+        }
+
         override fun onConnected() {
+            if (isStale("onConnected")) return
             Log.d(TAG, "Transport connected")
+            startHandshakeTimeout()
 
             if (connectionMode == ConnectionMode.PROXY && !authToken.isNullOrBlank()) {
                 // Proxy mode: send auth message first, then wait for auth_ok before hello.
@@ -1344,6 +1978,7 @@ class SendSpin(
             } else if (connectionMode == ConnectionMode.PROXY && authToken.isNullOrBlank()) {
                 // Proxy mode but no token available - auth will fail
                 Log.e(TAG, "Proxy connection has no auth token - server will reject")
+                recordError(FailureReason.AuthRejected, "No auth token for proxy connection")
                 _connectionState.value = TransportState.Failed(FailureReason.AuthRejected)
                 disconnect()
             } else {
@@ -1353,7 +1988,9 @@ class SendSpin(
         }
 
         override fun onMessage(text: String) {
+            if (isStale("text message")) return
             lastByteReceivedAtMs.set(System.currentTimeMillis())
+            bytesReceivedTotal.addAndGet(text.length.toLong())
             // Check for auth failure (server may send error if token is invalid)
             if (connectionMode == ConnectionMode.PROXY && !handshakeComplete) {
                 try {
@@ -1363,6 +2000,7 @@ class SendSpin(
                         val msg = json["message"]?.jsonPrimitive?.contentOrNull ?: "Authentication failed"
                         Log.e(TAG, "Proxy auth failed: $msg")
                         awaitingAuthResponse = false
+                        recordError(FailureReason.AuthRejected, msg)
                         _connectionState.value = TransportState.Failed(FailureReason.AuthRejected)
                         disconnect()
                         return
@@ -1387,7 +2025,9 @@ class SendSpin(
         }
 
         override fun onMessage(bytes: ByteArray) {
+            if (isStale("binary message")) return
             lastByteReceivedAtMs.set(System.currentTimeMillis())
+            bytesReceivedTotal.addAndGet(bytes.size.toLong())
             handleBinaryMessage(bytes)
         }
 
@@ -1396,7 +2036,9 @@ class SendSpin(
         }
 
         override fun onClosed(code: Int, reason: String) {
+            if (isStale("onClosed($code)")) return
             Log.d(TAG, "Transport closed: $code $reason")
+            stopPositionTicker()
 
             // Code 1000 = Normal Closure - server intentionally ended the session
             // This is NOT an error that should trigger reconnection
@@ -1412,19 +2054,16 @@ class SendSpin(
                 isNormalClosure = isNormalClosure,
             )
 
-            val hasConnectionInfo = when (connectionMode) {
-                ConnectionMode.LOCAL -> serverAddress != null
-                ConnectionMode.REMOTE -> remoteId != null
-                ConnectionMode.PROXY -> serverAddress != null && !authToken.isNullOrBlank()
-            }
+            if (!handshakeComplete) releaseFailedTransport()
 
-            if (!userInitiatedDisconnect.get() && !isNormalClosure && hasConnectionInfo) {
+            if (!userInitiatedDisconnect.get() && !isNormalClosure && hasConnectionInfo()) {
                 // Abnormal closure (not code 1000) - attempt reconnection. We no
                 // longer gate on handshakeComplete here; see class-level doc for
                 // the unified reconnect-gate policy (#129). Logging keeps the
                 // handshake state so field triage can still distinguish
                 // "pre-handshake drop" from "post-handshake drop".
                 Log.i(TAG, "Abnormal closure (code=$code, handshakeComplete=$handshakeComplete), attempting reconnection")
+                recordError(FailureReason.TransientNetwork, reason.ifEmpty { "Connection closed (code=$code)" })
                 if (selfReconnectEnabled) {
                     attemptReconnect()
                 } else {
@@ -1443,7 +2082,9 @@ class SendSpin(
         }
 
         override fun onFailure(error: Throwable, isRecoverable: Boolean) {
+            if (isStale("onFailure")) return
             Log.e(TAG, "Transport failure", error)
+            stopPositionTicker()
 
             // Record telemetry for the stats screen + emit the structured [disconnect]
             // log line. onFailure has no WebSocket close code -- use `null` code and
@@ -1454,14 +2095,13 @@ class SendSpin(
                 isNormalClosure = false,
             )
 
-            val hasConnectionInfo = when (connectionMode) {
-                ConnectionMode.LOCAL -> serverAddress != null
-                ConnectionMode.REMOTE -> remoteId != null
-                ConnectionMode.PROXY -> serverAddress != null && !authToken.isNullOrBlank()
-            }
+            if (!handshakeComplete) releaseFailedTransport()
+
+            val reason = classifyFailureReason(throwable = error)
+            recordError(reason, error.message ?: error::class.java.simpleName)
 
             val shouldReconnect = !userInitiatedDisconnect.get() &&
-                    hasConnectionInfo &&
+                    hasConnectionInfo() &&
                     isRecoverable
 
             if (shouldReconnect) {
@@ -1475,7 +2115,7 @@ class SendSpin(
                 }
             } else {
                 reconnecting.set(false)
-                _connectionState.value = TransportState.Failed(classifyFailureReason(throwable = error))
+                _connectionState.value = TransportState.Failed(reason)
             }
         }
     }
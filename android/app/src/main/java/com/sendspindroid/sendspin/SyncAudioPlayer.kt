@@ -10,6 +10,7 @@ import android.os.Process
 import com.sendspindroid.logging.AppLog
 import com.sendspindroid.sendspin.audio.AudioSink
 import com.sendspindroid.sendspin.audio.AudioTrackSink
+import com.sendspindroid.sendspin.audio.ChannelMixer
 import com.sendspindroid.sendspin.protocol.SendSpinProtocol
 import kotlinx.coroutines.CoroutineDispatcher
 import kotlinx.coroutines.CoroutineScope
@@ -193,7 +194,10 @@ enum class PlaybackState {
     /** Waiting for first audio chunk and time sync to be ready. */
     INITIALIZING,
 
-    /** Buffer filling, scheduled start time computed. Waiting for enough buffer and start time. */
+    /**
+     * Buffer filling, scheduled start time computed. Waiting for enough buffer and start time.
+     * Free-running playback also returns here from PLAYING after an underrun, to rebuffer.
+     */
     WAITING_FOR_START,
 
     /** Active synchronized playback with sample insert/drop corrections. */
@@ -269,6 +273,9 @@ class SyncAudioPlayer(
         ::defaultSinkFactory,
 ) {
     companion object {
+        // Track gain while ducked (about -14 dB), in line with the system's own ducking
+        private const val DUCK_GAIN = 0.2f
+
         // Sync correction thresholds (microseconds)
         private const val DEADBAND_THRESHOLD_US = 10_000L       // 10ms - no correction needed
         private const val HARD_RESYNC_THRESHOLD_US = 200_000L   // 200ms - hard resync (drop/skip chunks)
@@ -491,6 +498,17 @@ class SyncAudioPlayer(
 
     @Volatile private var syncMuted: Boolean = false
 
+    // Clock sync disabled: ignore server timestamps for scheduling; see setFreeRunning().
+    @Volatile private var freeRunning: Boolean = false
+    @Volatile private var freeRunningStartBufferMs: Long = MIN_BUFFER_BEFORE_START_MS.toLong()
+
+    // Local-only duck for transient "can duck" focus loss; see setDucked().
+    @Volatile private var ducked: Boolean = false
+
+    // Local balance and mono downmix applied to decoded PCM before it reaches
+    // the sink; see setBalance() and setMonoOutput().
+    private val channelMixer = ChannelMixer(channels, bitDepth)
+
     // 2D Kalman filter for sync error smoothing (tracks offset + drift)
     // Based on Python reference implementation for optimal noise filtering
     private val syncErrorFilter = SyncErrorFilter(
@@ -628,6 +646,7 @@ class SyncAudioPlayer(
 
         try {
             audioSink = sinkFactory(sampleRate, channels, bitDepth, bufferSize)
+            if (ducked) audioSink?.setVolume(DUCK_GAIN)
 
             // Pre-allocate frame buffers for sync correction (avoids GC in audio callback)
             lastOutputFrame = ByteArray(bytesPerFrame)
@@ -858,6 +877,77 @@ class SyncAudioPlayer(
         AppLog.Audio.i("Sync mute=$muted")
     }
 
+    /**
+     * Lower output for a transient "can duck" focus loss, or restore it.
+     *
+     * Applied as the AudioTrack's own gain, so it stacks on top of device
+     * volume and mute, and the server's volume for this player is untouched.
+     * Restoring returns to exactly full track gain. Survives AudioTrack
+     * re-creation (format change). Idempotent.
+     */
+    fun setDucked(ducked: Boolean) {
+        stateLock.withLock {
+            if (this.ducked == ducked) return
+            this.ducked = ducked
+            audioSink?.setVolume(if (ducked) DUCK_GAIN else 1f)
+            AppLog.Audio.i("Duck=$ducked")
+        }
+    }
+
+    /**
+     * Set local stereo balance, -1.0 (full left) to +1.0 (full right).
+     *
+     * Applied as per-channel gain on the decoded PCM, so it stacks with
+     * ducking, device volume, and mute. No effect on mono streams. The
+     * server's volume for this player is untouched.
+     */
+    fun setBalance(balance: Float) {
+        if (balance.isNaN() || balance !in -1f..1f) {
+            AppLog.Audio.w("Invalid balance $balance, ${if (balance.isNaN()) "using center" else "clamping"}")
+        }
+        channelMixer.balance = balance
+        AppLog.Audio.i("Balance=${channelMixer.balance}")
+    }
+
+    /**
+     * Sum stereo to mono (at -3 dB) and play it on both channels, for
+     * listeners with hearing in one ear. Runs before balance, so balance
+     * then pans the mono signal. No effect on mono streams.
+     */
+    fun setMonoOutput(enabled: Boolean) {
+        channelMixer.monoOutput = enabled
+        AppLog.Audio.i("Mono output=$enabled")
+    }
+
+    /**
+     * Play without clock sync ("local buffer" mode): chunks are queued
+     * without waiting for the time filter, playback starts once
+     * [startBufferMs] of audio is queued, and audio then runs at the
+     * device's own clock with no drift correction, reanchoring, or sync
+     * mute. An underrun goes back to buffering until [startBufferMs] is
+     * queued again.
+     *
+     * Use it for single-device listening on devices where sync correction
+     * is audible, or on networks too jittery to sync. Keep the default
+     * synced mode whenever this player shares a group: in local buffer
+     * mode it drifts out of alignment with the others. A larger buffer
+     * rides out longer network stalls at the cost of a slower start.
+     * Takes effect on the next chunk.
+     *
+     * @param startBufferMs Clamped to at least the synced mode's minimum
+     *   start buffer, which is also the default for zero or negative values.
+     *   Ignored when [enabled] is false.
+     */
+    fun setFreeRunning(enabled: Boolean, startBufferMs: Long = freeRunningStartBufferMs) {
+        val minStartMs = MIN_BUFFER_BEFORE_START_MS.toLong()
+        if (startBufferMs < minStartMs) {
+            AppLog.Audio.w("Free-running start buffer ${startBufferMs}ms below minimum, using ${minStartMs}ms")
+        }
+        freeRunningStartBufferMs = startBufferMs.coerceAtLeast(minStartMs)
+        freeRunning = enabled
+        AppLog.Audio.i("Free-running=$enabled, startBuffer=${freeRunningStartBufferMs}ms")
+    }
+
     /**
      * Stop playback and clear buffers.
      *
@@ -1271,8 +1361,8 @@ class SyncAudioPlayer(
         if (isReleased.get()) return
         chunksReceived++
 
-        // Buffer chunks until time sync is ready
-        if (!timeFilter.isReady) {
+        // Buffer chunks until time sync is ready (unless free-running)
+        if (!freeRunning && !timeFilter.isReady) {
             synchronized(pendingChunks) {
                 if (pendingChunks.size < MAX_PENDING_CHUNKS) {
                     pendingChunks.add(Pair(serverTimeMicros, pcmData))
@@ -2023,7 +2113,8 @@ class SyncAudioPlayer(
                         // (MIN_CHUNKS_BEFORE_START=16) added unnecessary delay and is now
                         // replaced by DAC timestamp stability tracking in preCalibrateDacTiming()
                         val bufferedMs = (totalQueuedSamples.get() * 1000) / sampleRate
-                        if (bufferedMs < MIN_BUFFER_BEFORE_START_MS) {
+                        val startBufferMs = if (freeRunning) freeRunningStartBufferMs else MIN_BUFFER_BEFORE_START_MS.toLong()
+                        if (bufferedMs < startBufferMs) {
                             // Pre-calibrate DAC timing while waiting for buffer to fill
                             // This establishes timing calibration BEFORE real audio arrives.
                             // Once stable, stop writing silence -- further writes just inflate
@@ -2035,8 +2126,13 @@ class SyncAudioPlayer(
                             continue
                         }
 
-                        // Handle start gating logic
-                        if (handleStartGating()) {
+                        // Handle start gating logic. Free-running has no
+                        // server-time schedule to wait for, so start now.
+                        if (freeRunning) {
+                            resetSyncBaselines(nowNs() / 1000)
+                            setPlaybackState(PlaybackState.PLAYING)
+                            AppLog.Audio.i("Free-running start: ${bufferedMs}ms buffered, now PLAYING")
+                        } else if (handleStartGating()) {
                             // Still waiting for scheduled start - continue pre-calibration
                             // only if timestamps aren't stable yet
                             if (!dacTimestampsStable) {
@@ -2102,6 +2198,11 @@ class SyncAudioPlayer(
                         continue
                     }
                     bufferUnderrunCount++
+                    if (freeRunning) {
+                        // Local buffer mode: refill to the start buffer before resuming
+                        AppLog.Audio.w("Free-running underrun - rebuffering ${freeRunningStartBufferMs}ms")
+                        setPlaybackState(PlaybackState.WAITING_FOR_START)
+                    }
                     delay(BUFFER_EMPTY_DELAY_MS)
                     continue
                 }
@@ -2131,7 +2232,7 @@ class SyncAudioPlayer(
                 }
 
                 // Reanchor if sync error is extremely large (e.g. after long pause/seek)
-                if (startTimeCalibrated && abs(syncErrorUs) > REANCHOR_THRESHOLD_US) {
+                if (!freeRunning && startTimeCalibrated && abs(syncErrorUs) > REANCHOR_THRESHOLD_US) {
                     AppLog.Sync.w("Large sync error: ${syncErrorUs/1000}ms, considering reanchor")
                     if (triggerReanchor()) {
                         continue
@@ -2283,8 +2384,9 @@ class SyncAudioPlayer(
      *        Sync error is obtained from [syncErrorFilter] (Kalman-filtered).
      */
     private fun updateCorrectionSchedule(@Suppress("UNUSED_PARAMETER") processingTimeErrorUs: Long) {
-        // Guard: Skip corrections until DAC calibration provides reliable sync error
-        if (!startTimeCalibrated) {
+        // Guard: Skip corrections until DAC calibration provides reliable sync error.
+        // Free-running plays at the device clock, so there is nothing to correct.
+        if (freeRunning || !startTimeCalibrated) {
             insertEveryNFrames = 0
             dropEveryNFrames = 0
             return
@@ -2381,8 +2483,10 @@ class SyncAudioPlayer(
 
         val track = audioSink ?: return
 
-        if (syncMuted && chunk.pcmData.isNotEmpty()) {
+        if (syncMuted && !freeRunning && chunk.pcmData.isNotEmpty()) {
             chunk.pcmData.fill(0)
+        } else {
+            channelMixer.process(chunk.pcmData)
         }
 
         // Track samples consumed for sync error calculation
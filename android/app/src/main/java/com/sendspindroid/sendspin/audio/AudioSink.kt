@@ -24,6 +24,12 @@ interface AudioSink {
     /** Release native resources. Mirrors AudioTrack.release(). */
     fun release()
 
+    /**
+     * Set this track's own output gain, 0.0-1.0, independent of the device
+     * stream volume. Mirrors AudioTrack.setVolume().
+     */
+    fun setVolume(gain: Float): Int
+
     /**
      * Write PCM data. Mirrors AudioTrack.write(buffer, offset, size) in
      * blocking mode. Returns the number of bytes written, or a negative
@@ -26,6 +26,7 @@ class AudioTrackSink(
     override fun stop() = track.stop()
     override fun flush() = track.flush()
     override fun release() = track.release()
+    override fun setVolume(gain: Float): Int = track.setVolume(gain)
 
     override fun write(buffer: ByteArray, offset: Int, size: Int): Int =
         track.write(buffer, offset, size)
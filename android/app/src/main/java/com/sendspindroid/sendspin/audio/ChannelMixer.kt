@@ -0,0 +1,103 @@
+package com.sendspindroid.sendspin.audio
+
+/**
+ * Local, per-player channel processing on decoded PCM, applied just before
+ * the samples are written to the [AudioSink].
+ *
+ * Mono downmix runs first: L and R are summed in Long at -3 dB and written
+ * to both channels, clamped to full scale. Balance is applied after, so with mono
+ * output on it steers the mono signal between the two sides rather than
+ * dropping one source channel.
+ *
+ * Balance attenuates one side only, so it never adds gain and never clips.
+ * Gains are applied in Double so 32-bit samples keep full precision, and the
+ * unattenuated side is never rescaled.
+ * Both compose multiplicatively with the sink's track gain (ducking) and the
+ * device volume, and are invisible to the server: the player's reported
+ * volume is unchanged.
+ *
+ * Mono streams pass through untouched. Supports the same sample formats as
+ * the sink (16-bit, packed 24-bit, 32-bit signed little-endian).
+ *
+ * Not thread-safe for [process]; settings may be changed from any thread
+ * and take effect on the next buffer.
+ */
+class ChannelMixer(
+    private val channels: Int,
+    bitDepth: Int,
+) {
+    companion object {
+        // -3 dB, so an uncorrelated L+R sum keeps roughly the same loudness.
+        // Double so a 32-bit sum isn't rounded to a Float mantissa.
+        private const val MONO_SUM_GAIN = 0.7071067811865476
+    }
+
+    private val bytesPerSample = bitDepth / 8
+    private val bytesPerFrame = channels * bytesPerSample
+    private val maxSample = (1L shl (bitDepth - 1)) - 1
+    private val minSample = -(1L shl (bitDepth - 1))
+
+    /**
+     * Stereo balance: -1.0 is full left, 0.0 centered, +1.0 full right.
+     * Out-of-range values are clamped; NaN centers.
+     */
+    @Volatile
+    var balance: Float = 0f
+        set(value) {
+            field = if (value.isNaN()) 0f else value.coerceIn(-1f, 1f)
+        }
+
+    /** Sum L+R to mono and play it on both channels. */
+    @Volatile
+    var monoOutput: Boolean = false
+
+    /** True when [process] would leave the samples unchanged. */
+    val isPassThrough: Boolean
+        get() = channels != 2 || (!monoOutput && balance == 0f)
+
+    /** Apply the current settings to [pcm] in place. */
+    fun process(pcm: ByteArray) {
+        if (isPassThrough) return
+
+        val mono = monoOutput
+        val b = balance
+        val leftGain = 1.0 - b.coerceAtLeast(0f)
+        val rightGain = 1.0 + b.coerceAtMost(0f)
+
+        var i = 0
+        val end = pcm.size - bytesPerFrame
+        while (i <= end) {
+            val r = i + bytesPerSample
+            var left = readSample(pcm, i)
+            var right = readSample(pcm, r)
+            if (mono) {
+                val sum = (left.toLong() + right.toLong()) * MONO_SUM_GAIN
+                left = sum.toLong().coerceIn(minSample, maxSample).toInt()
+                right = left
+            }
+            if (leftGain != 1.0) left = (left * leftGain).toInt()
+            if (rightGain != 1.0) right = (right * rightGain).toInt()
+            writeSample(pcm, i, left)
+            writeSample(pcm, r, right)
+            i += bytesPerFrame
+        }
+    }
+
+    private fun readSample(data: ByteArray, offset: Int): Int = when (bytesPerSample) {
+        2 -> (data[offset].toInt() and 0xFF) or
+            (data[offset + 1].toInt() shl 8)
+        3 -> (data[offset].toInt() and 0xFF) or
+            ((data[offset + 1].toInt() and 0xFF) shl 8) or
+            (data[offset + 2].toInt() shl 16)
+        else -> (data[offset].toInt() and 0xFF) or
+            ((data[offset + 1].toInt() and 0xFF) shl 8) or
+            ((data[offset + 2].toInt() and 0xFF) shl 16) or
+            (data[offset + 3].toInt() shl 24)
+    }
+
+    private fun writeSample(data: ByteArray, offset: Int, value: Int) {
+        for (k in 0 until bytesPerSample) {
+            data[offset + k] = (value shr (8 * k)).toByte()
+        }
+    }
+}
@@ -1,7 +1,10 @@
 package com.sendspindroid.sendspin.protocol
 
 import android.util.Log
+import com.sendspindroid.model.PlaybackStateType
 import com.sendspindroid.sendspin.AdaptiveBufferPolicy
+import com.sendspindroid.sendspin.ClockSyncStabilityMonitor
+import com.sendspindroid.sendspin.ServerOverflowDetector
 import com.sendspindroid.sendspin.SendspinTimeFilter
 import com.sendspindroid.sendspin.protocol.message.BinaryMessageParser
 import com.sendspindroid.sendspin.protocol.message.MessageBuilder
@@ -14,6 +17,7 @@ import kotlinx.serialization.json.contentOrNull
 import kotlinx.serialization.json.jsonArray
 import kotlinx.serialization.json.jsonObject
 import kotlinx.serialization.json.jsonPrimitive
+import java.util.concurrent.atomic.AtomicBoolean
 
 /**
  * Abstract base class for SendSpin protocol handling.
@@ -53,11 +57,16 @@ abstract class SendSpinProtocolHandler(
 
     // Stream active tracking (mirrors CLI _stream_active)
     private var _streamActive = false
+    @Volatile
     private var _currentStreamConfig: StreamConfig? = null
 
+    /** Format of the active stream, or null when no stream is running. */
+    protected val currentStreamConfig: StreamConfig?
+        get() = _currentStreamConfig
+
     // Last received values for change detection (avoids unnecessary UI recomposition)
     private var lastMetadata: TrackMetadata? = null
-    private var lastPlaybackState: String? = null
+    private var lastPlaybackState: PlaybackStateType? = null
     private var lastGroupInfo: GroupInfo? = null
 
     // Merged controller (group-level) state from server/state deltas.
@@ -75,6 +84,69 @@ abstract class SendSpinProtocolHandler(
     private val adaptiveBufferLock = Any()
     private var lastReportedMinBufferMs: Int = SendSpinProtocol.PlayerTiming.MIN_BUFFER_MS
 
+    // Watches for a filter that never converges. Reset on each startTimeSync();
+    // guarded by its own monitor for the same two-thread reason as above.
+    private val clockSyncMonitor = ClockSyncStabilityMonitor()
+
+    // Checks audio ingress against the advertised buffer capacity. Replaced on
+    // stream/start, reset on stream/clear; only touched on the receive thread.
+    private var overflowDetector: ServerOverflowDetector? = null
+
+    // Set by flushBuffers() on any thread; the receive thread resets
+    // [overflowDetector] before its next check, so the detector stays
+    // single-threaded.
+    private val overflowResetPending = AtomicBoolean(false)
+
+    // RTT of the last measurement applied to the time filter; -1 until one has been.
+    @Volatile
+    private var lastRttMicros: Long = -1L
+
+    /**
+     * Hold controller commands issued while a connection is being set up and
+     * send them once server/hello arrives, instead of dropping the tap.
+     * Off by default; callers opt in.
+     */
+    @Volatile
+    var queueCommandsWhileConnecting: Boolean = false
+
+    /**
+     * Largest artwork image accepted, in bytes. A bigger payload is dropped
+     * with a warning and the channel is cleared, so a buggy server can't make
+     * the app decode an arbitrarily large image. Zero or negative resets to
+     * [SendSpinProtocol.Artwork.MAX_BYTES] rather than rejecting all artwork.
+     */
+    @Volatile
+    var maxArtworkBytes: Int = SendSpinProtocol.Artwork.MAX_BYTES
+        set(value) {
+            field = if (value > 0) {
+                value
+            } else {
+                Log.w(tag, "Invalid maxArtworkBytes $value, using default ${SendSpinProtocol.Artwork.MAX_BYTES}")
+                SendSpinProtocol.Artwork.MAX_BYTES
+            }
+        }
+
+    /**
+     * Defensive handling for servers that mislabel binary frames: an "audio"
+     * frame whose payload starts with a JPEG or PNG signature goes to
+     * artwork channel 0 instead of the decoder, and a binary frame that is
+     * really a JSON object is handled as a text message. Off by default,
+     * since a real audio payload can, rarely, start with the same bytes.
+     */
+    @Volatile
+    var sniffMislabeledBinary: Boolean = false
+
+    // Commands waiting for the handshake. Guarded by itself; bounded by
+    // [MAX_PENDING_COMMANDS] and conflated by [conflationKey].
+    private val pendingCommands = ArrayDeque<PendingCommand>()
+
+    private class PendingCommand(
+        val command: String,
+        val volume: Int?,
+        val mute: Boolean?,
+        val queuedAtMs: Long
+    )
+
     // ========== Abstract Transport Methods ==========
 
     /**
@@ -82,6 +154,12 @@ abstract class SendSpinProtocolHandler(
      */
     protected abstract fun sendTextMessage(text: String)
 
+    /**
+     * Whether a connection attempt (including a reconnect) is in flight,
+     * i.e. commands issued now will have somewhere to go shortly.
+     */
+    protected open fun isConnecting(): Boolean = false
+
     /**
      * Get the coroutine scope for async operations.
      */
@@ -120,9 +198,10 @@ abstract class SendSpinProtocolHandler(
     protected abstract fun onMetadataUpdate(metadata: TrackMetadata)
 
     /**
-     * Called when playback state changes.
+     * Called when playback state changes. Unrecognized wire values arrive
+     * as [PlaybackStateType.UNKNOWN].
      */
-    protected abstract fun onPlaybackStateChanged(state: String)
+    protected abstract fun onPlaybackStateChanged(state: PlaybackStateType)
 
     /**
      * Called when server sends a volume command.
@@ -149,6 +228,12 @@ abstract class SendSpinProtocolHandler(
      */
     protected abstract fun onStreamClear()
 
+    /**
+     * Called by [flushBuffers] to drop locally buffered audio and decoder
+     * state. Unlike [onStreamClear] the stream is still running.
+     */
+    protected abstract fun onFlushBuffers()
+
     /**
      * Called when stream ends (server terminates playback).
      */
@@ -187,6 +272,24 @@ abstract class SendSpinProtocolHandler(
      */
     protected abstract fun onSyncMuteChanged(muted: Boolean)
 
+    /**
+     * Called once when clock sync has failed to converge for
+     * [ClockSyncStabilityMonitor.DEFAULT_UNSTABLE_AFTER_MS] (see
+     * [ClockSyncStabilityMonitor] for the criteria). [jitterMicros] is the
+     * interquartile range of recent RTTs, [errorMicros] the filter's
+     * estimated offset error. Default no-op.
+     */
+    protected open fun onClockSyncUnstable(jitterMicros: Long, errorMicros: Long) {}
+
+    /**
+     * Called once per stream when the server has sent more audio than the
+     * buffer capacity we advertised allows (see [ServerOverflowDetector]).
+     * [observedBytesPerSec] is the mean ingress rate since the stream
+     * started, [advertisedBytesPerSec] the highest rate we can consume.
+     * Default no-op.
+     */
+    protected open fun onServerOverflow(observedBytesPerSec: Long, advertisedBytesPerSec: Long) {}
+
     // ========== Protocol Message Sending ==========
 
     /**
@@ -212,24 +315,32 @@ abstract class SendSpinProtocolHandler(
      */
     protected fun sendClientHello() {
         val formats = getSupportedFormats()
-        val bufferDuration = if (isLowMemoryMode()) {
-            SendSpinProtocol.Buffer.DURATION_LOW_MEM_SEC
-        } else {
-            SendSpinProtocol.Buffer.DURATION_NORMAL_SEC
-        }
-        val bufferCapacity = MessageBuilder.calculateBufferCapacity(formats, bufferDuration)
         val text = MessageBuilder.buildClientHello(
             clientId = getClientId(),
             deviceName = getDeviceName(),
-            bufferCapacity = bufferCapacity,
+            bufferCapacity = bufferCapacity(formats),
             manufacturer = getManufacturer(),
             supportedFormats = formats,
+            lowMemoryMode = isLowMemoryMode(),
             softwareVersion = getSoftwareVersion()
         )
         sendTextMessage(text)
         Log.d(tag, "Sent client/hello: ${text.take(500)}")
     }
 
+    /** Roles advertised in client/hello. */
+    protected fun supportedRoles(): List<String> = MessageBuilder.supportedRoles(isLowMemoryMode())
+
+    /** Buffer capacity in wire bytes advertised in client/hello for [formats]. */
+    protected fun bufferCapacity(formats: List<MessageBuilder.FormatEntry>): Int =
+        MessageBuilder.calculateBufferCapacity(formats, bufferDurationSec())
+
+    private fun bufferDurationSec(): Int = if (isLowMemoryMode()) {
+        SendSpinProtocol.Buffer.DURATION_LOW_MEM_SEC
+    } else {
+        SendSpinProtocol.Buffer.DURATION_NORMAL_SEC
+    }
+
     /**
      * Send client/time message for clock synchronization.
      */
@@ -271,6 +382,7 @@ abstract class SendSpinProtocolHandler(
      * previous [onMeasurementApplied] behavior.
      */
     private fun onTimeMeasurement(rttMicros: Long) {
+        lastRttMicros = rttMicros
         val filter = getTimeFilter()
         val quality = when {
             filter.isReady && filter.isConverged -> AdaptiveBufferPolicy.SyncQuality.GOOD
@@ -291,12 +403,26 @@ abstract class SendSpinProtocolHandler(
             }
         }
         evaluateAndPublishSyncState()
+        val unstable = synchronized(clockSyncMonitor) {
+            clockSyncMonitor.update(
+                nowMs = android.os.SystemClock.elapsedRealtime(),
+                converged = quality == AdaptiveBufferPolicy.SyncQuality.GOOD
+            )
+        }
+        if (unstable) {
+            val jitterMicros = timeSyncManager?.rttJitterMicros ?: 0L
+            Log.w(tag, "Clock sync not converging: jitter=${jitterMicros}us, error=${filter.errorMicros}us")
+            onClockSyncUnstable(jitterMicros, filter.errorMicros)
+        }
         if (changed && handshakeComplete) {
             Log.d(tag, "Adaptive min_buffer_ms -> ${adaptiveBuffer?.currentTargetMs}")
             sendPlayerStateUpdate()
         }
     }
 
+    /** RTT in microseconds of the last applied time sync measurement, or -1. */
+    fun getLastRttMicros(): Long = lastRttMicros
+
     /**
      * Public hook for code outside the protocol handler (e.g.
      * [OutputLatencyEstimator] via [SyncAudioPlayer]) to push a fresh
@@ -348,7 +474,8 @@ abstract class SendSpinProtocolHandler(
                 currentSyncState = "external_source"
             } else {
                 val filter = getTimeFilter()
-                currentSyncState = if (filter.isReady && filter.isConverged) "synchronized" else "error"
+                val synced = (filter.isReady && filter.isConverged) || isClockSyncOff()
+                currentSyncState = if (synced) "synchronized" else "error"
             }
             true
         }
@@ -367,6 +494,11 @@ abstract class SendSpinProtocolHandler(
      * successful sync has been established at least once and is then lost
      * — the initial pre-sync window does not silence playback.
      *
+     * With clock sync turned off (time sync in keepalive-only mode) the
+     * filter never updates and playback free-runs without it, so the state
+     * is "synchronized" and sync mute is never requested. Re-run this when
+     * that mode is toggled.
+     *
      * Idempotent: only fires server / mute notifications on transitions.
      * Safe to call from any thread.
      */
@@ -381,11 +513,12 @@ abstract class SendSpinProtocolHandler(
             if (converged) {
                 hasEverConverged = true
             }
+            val clockSyncOff = isClockSyncOff()
 
-            val desiredState = if (converged) "synchronized" else "error"
+            val desiredState = if (converged || clockSyncOff) "synchronized" else "error"
             setSyncState(desiredState)
 
-            val desiredMute = hasEverConverged && desiredState == "error"
+            val desiredMute = !clockSyncOff && hasEverConverged && desiredState == "error"
             if (desiredMute != lastPublishedMute) {
                 lastPublishedMute = desiredMute
                 desiredMute
@@ -398,6 +531,8 @@ abstract class SendSpinProtocolHandler(
         }
     }
 
+    private fun isClockSyncOff(): Boolean = timeSyncManager?.keepaliveOnly == true
+
     /**
      * Reset all sync-state tracking back to "before any sync has been
      * achieved on this server." Call this on a fresh connection to a new
@@ -430,10 +565,17 @@ abstract class SendSpinProtocolHandler(
      * supported_commands; once the server has told us its set, anything
      * outside it is dropped (the server would ignore it anyway).
      *
+     * Commands issued while connecting are queued and sent after
+     * server/hello (see [queueCommandsWhileConnecting]).
+     *
      * @param volume only used when [command] is "volume"
      * @param mute only used when [command] is "mute"
      */
     fun sendCommand(command: String, volume: Int? = null, mute: Boolean? = null) {
+        if (!handshakeComplete && queueCommandsWhileConnecting && isConnecting()) {
+            enqueueCommand(command, volume, mute)
+            return
+        }
         val supported = currentControllerState?.supportedCommands
         if (supported != null && command !in supported) {
             Log.w(tag, "Dropping controller command '$command': not in server supported_commands $supported")
@@ -442,6 +584,53 @@ abstract class SendSpinProtocolHandler(
         sendTextMessage(MessageBuilder.buildCommand(command, volume, mute))
     }
 
+    private fun enqueueCommand(command: String, volume: Int?, mute: Boolean?) {
+        synchronized(pendingCommands) {
+            // A newer volume/mute/transport/repeat/shuffle command supersedes
+            // an older one of the same kind; sending both just flaps the server.
+            val key = conflationKey(command)
+            if (key != null) pendingCommands.removeAll { conflationKey(it.command) == key }
+            if (pendingCommands.size >= MAX_PENDING_COMMANDS) {
+                val dropped = pendingCommands.removeFirst()
+                Log.w(tag, "Command queue full, dropping '${dropped.command}'")
+            }
+            pendingCommands.addLast(PendingCommand(command, volume, mute, System.currentTimeMillis()))
+            Log.d(tag, "Queued '$command' until handshake completes (${pendingCommands.size} pending)")
+        }
+    }
+
+    /**
+     * Send commands queued during connection setup, skipping any older than
+     * [PENDING_COMMAND_TTL_MS]: a tap from a long failed attempt shouldn't
+     * fire when a later connection finally succeeds.
+     */
+    private fun flushPendingCommands() {
+        val now = System.currentTimeMillis()
+        val ready = synchronized(pendingCommands) {
+            val fresh = pendingCommands.filter { now - it.queuedAtMs <= PENDING_COMMAND_TTL_MS }
+            pendingCommands.clear()
+            fresh
+        }
+        if (ready.isEmpty()) return
+        Log.i(tag, "Sending ${ready.size} command(s) queued during connect")
+        ready.forEach { sendCommand(it.command, it.volume, it.mute) }
+    }
+
+    /**
+     * Drop queued commands, e.g. on a user-initiated disconnect.
+     */
+    protected fun clearPendingCommands() {
+        synchronized(pendingCommands) { pendingCommands.clear() }
+    }
+
+    private fun conflationKey(command: String): String? = when (command) {
+        "volume", "mute" -> command
+        "play", "pause", "stop" -> "transport"
+        "repeat_off", "repeat_one", "repeat_all" -> "repeat"
+        "shuffle", "unshuffle" -> "shuffle"
+        else -> null  // next/previous/switch are cumulative, keep each
+    }
+
     /**
      * Request a different stream format from the server (spec
      * stream/request-format). Omitted fields keep their current value.
@@ -464,9 +653,17 @@ abstract class SendSpinProtocolHandler(
     /**
      * Set volume and notify server.
      *
-     * @param volume Volume level from 0.0 to 1.0
+     * @param volume Volume level from 0.0 to 1.0; out-of-range values are
+     *   clamped and NaN is ignored, both with a warning
      */
     fun setVolume(volume: Double) {
+        if (volume.isNaN()) {
+            Log.w(tag, "Invalid volume NaN, keeping $currentVolume%")
+            return
+        }
+        if (volume !in 0.0..1.0) {
+            Log.w(tag, "Volume $volume out of range, clamping")
+        }
         val volumePercent = (volume * 100).toInt().coerceIn(0, 100)
         currentVolume = volumePercent
         Log.d(tag, "setVolume: $volumePercent%")
@@ -485,10 +682,13 @@ abstract class SendSpinProtocolHandler(
     /**
      * Set initial volume before handshake.
      *
-     * @param volume Volume level from 0 to 100
+     * @param volume Volume level from 0 to 100; out-of-range values are clamped
      * @param muted Whether audio is muted
      */
     fun setInitialVolume(volume: Int, muted: Boolean = false) {
+        if (volume !in 0..100) {
+            Log.w(tag, "Initial volume $volume out of range, clamping")
+        }
         currentVolume = volume.coerceIn(0, 100)
         currentMuted = muted
         Log.d(tag, "Initial volume set: $currentVolume, muted=$currentMuted")
@@ -502,6 +702,7 @@ abstract class SendSpinProtocolHandler(
     protected fun startTimeSync() {
         val manager = timeSyncManager
         if (manager != null && !manager.isRunning) {
+            synchronized(clockSyncMonitor) { clockSyncMonitor.reset() }
             manager.start(getCoroutineScope())
         }
     }
@@ -578,6 +779,7 @@ abstract class SendSpinProtocolHandler(
         // Clear cached values so the first post-handshake messages always propagate
         _streamActive = false
         _currentStreamConfig = null
+        overflowDetector = null
         lastMetadata = null
         lastPlaybackState = null
         lastGroupInfo = null
@@ -587,6 +789,7 @@ abstract class SendSpinProtocolHandler(
 
         sendPlayerStateUpdate()
         startTimeSync()
+        flushPendingCommands()
     }
 
     protected fun handleServerTime(payload: JsonObject?) {
@@ -677,15 +880,33 @@ abstract class SendSpinProtocolHandler(
 
         _streamActive = true
         _currentStreamConfig = config
+        val capacity = bufferCapacity(getSupportedFormats()).toLong()
+        overflowDetector = ServerOverflowDetector(capacity, capacity / bufferDurationSec())
         onStreamStart(config)
     }
 
     protected fun handleStreamClear() {
         Log.i(tag, "[cmd-trace] T1 handleStreamClear ts=${System.nanoTime() / 1_000_000} thread=${Thread.currentThread().name}")
         Log.v(tag, "Stream clear - flushing audio buffers")
+        // The server may legitimately refill the whole buffer after a clear
+        overflowDetector?.reset()
         onStreamClear()
     }
 
+    /**
+     * Local recovery action: drop everything buffered for the current stream
+     * (player buffer, decoder state, overflow tracking) as a server
+     * stream/clear would, without ending the stream or touching the
+     * connection. Audio that arrives afterwards plays as normal. Safe to call
+     * from any thread, with or without an active stream.
+     */
+    fun flushBuffers() {
+        Log.i(tag, "Flushing buffers (local request), streamActive=$_streamActive")
+        // The detector belongs to the receive thread; hand the reset over
+        overflowResetPending.set(true)
+        onFlushBuffers()
+    }
+
     protected fun handleStreamEnd(payload: JsonObject?) {
         Log.i(tag, "[cmd-trace] T1 handleStreamEnd ts=${System.nanoTime() / 1_000_000} thread=${Thread.currentThread().name}")
         val rolesArray = payload?.get("roles")?.jsonArray
@@ -699,6 +920,7 @@ abstract class SendSpinProtocolHandler(
         Log.i(tag, "Stream end - server terminated playback (roles=${roles ?: "all"})")
         _streamActive = false
         _currentStreamConfig = null
+        overflowDetector = null
         onStreamEnd()
     }
 
@@ -728,6 +950,12 @@ abstract class SendSpinProtocolHandler(
      * Handle binary message from the transport.
      */
     protected fun handleBinaryMessage(bytes: ByteArray) {
+        // '{' is no binary message type; it's a JSON message in the wrong frame
+        if (sniffMislabeledBinary && bytes.isNotEmpty() && bytes[0] == '{'.code.toByte()) {
+            Log.w(tag, "Binary frame carries JSON (${bytes.size} bytes), handling as text")
+            handleTextMessage(bytes.decodeToString())
+            return
+        }
         val message = BinaryMessageParser.parse(bytes)
         if (message != null) {
             dispatchBinaryMessage(message)
@@ -740,17 +968,23 @@ abstract class SendSpinProtocolHandler(
     private fun dispatchBinaryMessage(message: BinaryMessageParser.BinaryMessage) {
         when (message) {
             is BinaryMessageParser.BinaryMessage.Audio -> {
+                if (sniffMislabeledBinary && looksLikeImage(message.payload)) {
+                    Log.w(tag, "Audio frame carries an image (${message.payload.size} bytes), treating as artwork")
+                    deliverArtwork(0, message.payload)
+                    return
+                }
                 // Spec: binary messages should be rejected if there is no
                 // active stream (e.g. chunks in flight after stream/end).
                 if (!_streamActive) {
                     Log.v(tag, "Dropping audio chunk: no active stream")
                     return
                 }
+                checkServerOverflow(message.payload.size)
                 onAudioChunk(message.timestampMicros, message.payload)
             }
             is BinaryMessageParser.BinaryMessage.Artwork -> {
                 Log.v(tag, "Received artwork channel ${message.channel}: ${message.payload.size} bytes")
-                onArtwork(message.channel, message.payload)
+                deliverArtwork(message.channel, message.payload)
             }
             is BinaryMessageParser.BinaryMessage.Visualizer -> {
                 // Visualization data - currently not used, no logging needed
@@ -760,4 +994,42 @@ abstract class SendSpinProtocolHandler(
             }
         }
     }
+
+    private fun checkServerOverflow(bytes: Int) {
+        val detector = overflowDetector ?: return
+        if (overflowResetPending.getAndSet(false)) detector.reset()
+        val observed = detector.update(android.os.SystemClock.elapsedRealtime(), bytes) ?: return
+        val advertised = bufferCapacity(getSupportedFormats()).toLong() / bufferDurationSec()
+        Log.w(tag, "Server exceeding advertised buffer capacity: $observed B/s vs $advertised B/s")
+        onServerOverflow(observed, advertised)
+    }
+
+    private fun deliverArtwork(channel: Int, payload: ByteArray) {
+        if (payload.size > maxArtworkBytes) {
+            Log.w(tag, "Artwork channel $channel too large: ${payload.size} bytes (limit $maxArtworkBytes), clearing")
+            // Clear rather than keep showing the previous track's image
+            onArtwork(channel, ByteArray(0))
+            return
+        }
+        onArtwork(channel, payload)
+    }
+
+    /** The full 8-byte PNG signature, or a JPEG SOI followed by an APPn/DQT marker. */
+    private fun looksLikeImage(payload: ByteArray): Boolean {
+        if (startsWith(payload, PNG_MAGIC)) return true
+        if (!startsWith(payload, JPEG_MAGIC) || payload.size < 4) return false
+        val marker = payload[3].toInt() and 0xFF
+        return (marker and 0xF0) == 0xE0 || marker == 0xDB
+    }
+
+    private fun startsWith(payload: ByteArray, prefix: ByteArray): Boolean =
+        payload.size >= prefix.size && prefix.indices.all { payload[it] == prefix[it] }
+
+    private companion object {
+        val JPEG_MAGIC = byteArrayOf(0xFF.toByte(), 0xD8.toByte(), 0xFF.toByte())
+        val PNG_MAGIC = byteArrayOf(0x89.toByte(), 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A)
+
+        const val MAX_PENDING_COMMANDS = 8
+        const val PENDING_COMMAND_TTL_MS = 10_000L
+    }
 }
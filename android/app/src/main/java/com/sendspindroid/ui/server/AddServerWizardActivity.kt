@@ -21,6 +21,7 @@ import com.sendspindroid.UnifiedServerRepository
 import com.sendspindroid.discovery.NsdDiscoveryManager
 import com.sendspindroid.model.ConnectionPreference
 import com.sendspindroid.model.LocalConnection
+import com.sendspindroid.model.PlaybackStateType
 import com.sendspindroid.model.ProxyConnection
 import com.sendspindroid.model.UnifiedServer
 import com.sendspindroid.musicassistant.MaSettings
@@ -75,8 +76,8 @@ class AddServerWizardActivity : FragmentActivity() {
     // All methods are intentionally empty — the wizard only cares about connectionState.
     private val noopSendSpinCallback = object : SendSpin.Callback {
         override fun onServerDiscovered(name: String, address: String) {}
-        override fun onStateChanged(state: String) {}
-        override fun onGroupUpdate(groupId: String, groupName: String, playbackState: String) {}
+        override fun onStateChanged(state: PlaybackStateType) {}
+        override fun onGroupUpdate(groupId: String, groupName: String, playbackState: PlaybackStateType?) {}
         override fun onMetadataUpdate(
             title: String, artist: String, album: String,
             artworkUrl: String, durationMs: Long, positionMs: Long, playbackSpeed: Int
@@ -32,6 +32,7 @@ import androidx.compose.material3.Scaffold
 import androidx.compose.material3.SegmentedButton
 import androidx.compose.material3.SegmentedButtonDefaults
 import androidx.compose.material3.SingleChoiceSegmentedButtonRow
+import androidx.compose.material3.Slider
 import androidx.compose.material3.Switch
 import androidx.compose.material3.Text
 import androidx.compose.material3.TextButton
@@ -40,6 +41,7 @@ import androidx.compose.material3.TopAppBarDefaults
 import androidx.compose.runtime.Composable
 import androidx.lifecycle.compose.collectAsStateWithLifecycle
 import androidx.compose.runtime.getValue
+import androidx.compose.runtime.mutableFloatStateOf
 import androidx.compose.runtime.mutableStateOf
 import androidx.compose.runtime.remember
 import androidx.compose.runtime.setValue
@@ -58,6 +60,8 @@ import com.sendspindroid.UserSettings
 import com.sendspindroid.diagnostics.Telemetry
 import com.sendspindroid.logging.LogLevel
 import com.sendspindroid.ui.theme.SendSpinTheme
+import kotlin.math.abs
+import kotlin.math.roundToInt
 
 /**
  * Settings screen composable with all app preferences.
@@ -85,6 +89,10 @@ fun SettingsScreen(
     val syncOffset by viewModel.syncOffset.collectAsStateWithLifecycle()
     val preferredCodec by viewModel.preferredCodec.collectAsStateWithLifecycle()
     val supportedCodecs by viewModel.supportedCodecs.collectAsStateWithLifecycle()
+    val balance by viewModel.balance.collectAsStateWithLifecycle()
+    val monoOutput by viewModel.monoOutput.collectAsStateWithLifecycle()
+    val disableClockSync by viewModel.disableClockSync.collectAsStateWithLifecycle()
+    val localBufferMs by viewModel.localBufferMs.collectAsStateWithLifecycle()
     val lowMemoryMode by viewModel.lowMemoryMode.collectAsStateWithLifecycle()
     val highPowerMode by viewModel.highPowerMode.collectAsStateWithLifecycle()
     val autoStartOnBoot by viewModel.autoStartOnBoot.collectAsStateWithLifecycle()
@@ -176,11 +184,33 @@ fun SettingsScreen(
                 onIncrease = { viewModel.increaseSyncOffset() },
                 onValueClick = { showSyncOffsetDialog = true }
             )
+            SwitchPreference(
+                title = stringResource(R.string.pref_disable_clock_sync_title),
+                summary = stringResource(R.string.pref_disable_clock_sync_summary),
+                checked = disableClockSync,
+                onCheckedChange = { viewModel.setDisableClockSync(it) }
+            )
+            if (disableClockSync) {
+                LocalBufferPreference(
+                    bufferMs = localBufferMs,
+                    onBufferChange = { viewModel.setLocalBufferMs(it) }
+                )
+            }
             CodecPreference(
                 title = stringResource(R.string.pref_codec_title),
                 summary = getCodecDisplayName(preferredCodec),
                 onClick = { showCodecDialog = true }
             )
+            SwitchPreference(
+                title = stringResource(R.string.pref_mono_output_title),
+                summary = stringResource(R.string.pref_mono_output_summary),
+                checked = monoOutput,
+                onCheckedChange = { viewModel.setMonoOutput(it) }
+            )
+            BalancePreference(
+                balance = balance,
+                onBalanceChange = { viewModel.setBalance(it) }
+            )
 
             // Performance Category
             PreferenceCategory(title = stringResource(R.string.pref_category_performance))
@@ -584,6 +614,96 @@ private fun SyncOffsetPreference(
     }
 }
 
+@Composable
+private fun BalancePreference(
+    balance: Float,
+    onBalanceChange: (Float) -> Unit,
+    modifier: Modifier = Modifier
+) {
+    // Track the thumb locally while dragging; persist once on release
+    var sliderValue by remember(balance) { mutableFloatStateOf(balance) }
+    val percent = (abs(sliderValue) * 100).roundToInt()
+
+    Column(
+        modifier = modifier
+            .fillMaxWidth()
+            .padding(horizontal = 16.dp, vertical = 12.dp)
+    ) {
+        Row(verticalAlignment = Alignment.CenterVertically) {
+            Text(
+                text = stringResource(R.string.pref_balance_title),
+                style = MaterialTheme.typography.bodyLarge,
+                modifier = Modifier.weight(1f)
+            )
+            Text(
+                text = when {
+                    percent == 0 -> stringResource(R.string.pref_balance_center)
+                    sliderValue < 0f -> stringResource(R.string.pref_balance_left, percent)
+                    else -> stringResource(R.string.pref_balance_right, percent)
+                },
+                style = MaterialTheme.typography.bodyMedium,
+                color = MaterialTheme.colorScheme.primary
+            )
+        }
+        Spacer(modifier = Modifier.height(2.dp))
+        Text(
+            text = stringResource(R.string.pref_balance_summary),
+            style = MaterialTheme.typography.bodyMedium,
+            color = MaterialTheme.colorScheme.onSurfaceVariant
+        )
+        Slider(
+            value = sliderValue,
+            onValueChange = { sliderValue = it },
+            onValueChangeFinished = { onBalanceChange(sliderValue) },
+            valueRange = -1f..1f,
+            steps = 19
+        )
+    }
+}
+
+@Composable
+private fun LocalBufferPreference(
+    bufferMs: Int,
+    onBufferChange: (Int) -> Unit,
+    modifier: Modifier = Modifier
+) {
+    // Track the thumb locally while dragging; persist once on release
+    var sliderValue by remember(bufferMs) { mutableFloatStateOf(bufferMs.toFloat()) }
+
+    Column(
+        modifier = modifier
+            .fillMaxWidth()
+            .padding(horizontal = 16.dp, vertical = 12.dp)
+    ) {
+        Row(verticalAlignment = Alignment.CenterVertically) {
+            Text(
+                text = stringResource(R.string.pref_local_buffer_title),
+                style = MaterialTheme.typography.bodyLarge,
+                modifier = Modifier.weight(1f)
+            )
+            Text(
+                text = stringResource(R.string.pref_local_buffer_value, sliderValue.roundToInt()),
+                style = MaterialTheme.typography.bodyMedium,
+                color = MaterialTheme.colorScheme.primary
+            )
+        }
+        Spacer(modifier = Modifier.height(2.dp))
+        Text(
+            text = stringResource(R.string.pref_local_buffer_summary),
+            style = MaterialTheme.typography.bodyMedium,
+            color = MaterialTheme.colorScheme.onSurfaceVariant
+        )
+        Slider(
+            value = sliderValue,
+            onValueChange = { sliderValue = it },
+            onValueChangeFinished = { onBufferChange(sliderValue.roundToInt()) },
+            valueRange = UserSettings.LOCAL_BUFFER_MIN.toFloat()..UserSettings.LOCAL_BUFFER_MAX.toFloat(),
+            // 200 ms steps
+            steps = (UserSettings.LOCAL_BUFFER_MAX - UserSettings.LOCAL_BUFFER_MIN) / 200 - 1
+        )
+    }
+}
+
 @OptIn(ExperimentalMaterial3Api::class)
 @Composable
 private fun <T> SegmentedButtonPreference(
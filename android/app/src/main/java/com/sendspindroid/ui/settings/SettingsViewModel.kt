@@ -42,6 +42,14 @@ class SettingsViewModel(application: Application) : AndroidViewModel(application
         const val EXTRA_HIGH_POWER_MODE_ENABLED = "high_power_mode_enabled"
         const val ACTION_PREFERRED_CODEC_CHANGED = "com.sendspindroid.ACTION_PREFERRED_CODEC_CHANGED"
         const val EXTRA_PREFERRED_CODEC = "preferred_codec"
+        const val ACTION_BALANCE_CHANGED = "com.sendspindroid.ACTION_BALANCE_CHANGED"
+        const val EXTRA_BALANCE = "balance"
+        const val ACTION_MONO_OUTPUT_CHANGED = "com.sendspindroid.ACTION_MONO_OUTPUT_CHANGED"
+        const val EXTRA_MONO_OUTPUT_ENABLED = "mono_output_enabled"
+        const val ACTION_CLOCK_SYNC_CHANGED = "com.sendspindroid.ACTION_CLOCK_SYNC_CHANGED"
+        const val EXTRA_CLOCK_SYNC_DISABLED = "clock_sync_disabled"
+        const val ACTION_LOCAL_BUFFER_CHANGED = "com.sendspindroid.ACTION_LOCAL_BUFFER_CHANGED"
+        const val EXTRA_LOCAL_BUFFER_MS = "local_buffer_ms"
     }
 
     private val prefs = PreferenceManager.getDefaultSharedPreferences(application)
@@ -70,6 +78,18 @@ class SettingsViewModel(application: Application) : AndroidViewModel(application
     private val _preferredCodec = MutableStateFlow(UserSettings.getPreferredCodec())
     val preferredCodec: StateFlow<String> = _preferredCodec.asStateFlow()
 
+    private val _balance = MutableStateFlow(UserSettings.balance)
+    val balance: StateFlow<Float> = _balance.asStateFlow()
+
+    private val _monoOutput = MutableStateFlow(UserSettings.monoOutput)
+    val monoOutput: StateFlow<Boolean> = _monoOutput.asStateFlow()
+
+    private val _disableClockSync = MutableStateFlow(UserSettings.disableClockSync)
+    val disableClockSync: StateFlow<Boolean> = _disableClockSync.asStateFlow()
+
+    private val _localBufferMs = MutableStateFlow(UserSettings.localBufferMs)
+    val localBufferMs: StateFlow<Int> = _localBufferMs.asStateFlow()
+
     private val _supportedCodecs = MutableStateFlow(computeSupportedCodecs())
     val supportedCodecs: StateFlow<Set<String>> = _supportedCodecs.asStateFlow()
 
@@ -192,6 +212,49 @@ class SettingsViewModel(application: Application) : AndroidViewModel(application
         LocalBroadcastManager.getInstance(getApplication()).sendBroadcast(intent)
     }
 
+    fun setBalance(balance: Float) {
+        val clamped = balance.coerceIn(-1f, 1f)
+        UserSettings.balance = clamped
+        _balance.value = clamped
+
+        // Broadcast to PlaybackService so the live player picks it up
+        val intent = Intent(ACTION_BALANCE_CHANGED).apply {
+            putExtra(EXTRA_BALANCE, clamped)
+        }
+        LocalBroadcastManager.getInstance(getApplication()).sendBroadcast(intent)
+    }
+
+    fun setMonoOutput(enabled: Boolean) {
+        UserSettings.monoOutput = enabled
+        _monoOutput.value = enabled
+
+        val intent = Intent(ACTION_MONO_OUTPUT_CHANGED).apply {
+            putExtra(EXTRA_MONO_OUTPUT_ENABLED, enabled)
+        }
+        LocalBroadcastManager.getInstance(getApplication()).sendBroadcast(intent)
+    }
+
+    fun setDisableClockSync(disabled: Boolean) {
+        UserSettings.disableClockSync = disabled
+        _disableClockSync.value = disabled
+
+        val intent = Intent(ACTION_CLOCK_SYNC_CHANGED).apply {
+            putExtra(EXTRA_CLOCK_SYNC_DISABLED, disabled)
+        }
+        LocalBroadcastManager.getInstance(getApplication()).sendBroadcast(intent)
+    }
+
+    fun setLocalBufferMs(bufferMs: Int) {
+        UserSettings.localBufferMs = bufferMs
+        val clamped = UserSettings.localBufferMs
+        _localBufferMs.value = clamped
+
+        val intent = Intent(ACTION_LOCAL_BUFFER_CHANGED).apply {
+            putExtra(EXTRA_LOCAL_BUFFER_MS, clamped)
+        }
+        LocalBroadcastManager.getInstance(getApplication()).sendBroadcast(intent)
+    }
+
     // Performance settings
     /**
      * Sets low memory mode.
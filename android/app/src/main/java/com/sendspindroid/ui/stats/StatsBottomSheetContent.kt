@@ -159,6 +159,13 @@ fun StatsContent(
         StatRow(stringResource(R.string.stats_converged), if (state.clockConverged) stringResource(R.string.action_yes) else stringResource(R.string.action_no),
             if (state.clockConverged) ColorGood else ColorWarning)
         StatRow(stringResource(R.string.stats_measurements), state.measurementCount.toString())
+        if (state.clockSyncUnstableJitterUs >= 0) {
+            StatRow(
+                stringResource(R.string.stats_sync_unstable),
+                stringResource(R.string.stats_sync_unstable_jitter, state.clockSyncUnstableJitterUs / 1000.0),
+                ColorBad,
+            )
+        }
         // Kalman-filter health. `stability` should be ~1.0 for a well-tuned filter;
         // < 1 = over-responsive, > 1 = sluggish. `convergence` is time from first
         // measurement to first isConverged==true. Issue #128.
@@ -190,6 +197,8 @@ fun StatsContent(
 
         // === DAC / AUDIO ===
         SectionHeader(stringResource(R.string.stats_section_dac_audio))
+        StatRow(stringResource(R.string.stats_audio_started), if (state.audioStarted) stringResource(R.string.action_yes) else stringResource(R.string.action_no),
+            if (state.audioStarted) ColorGood else null)
         StatRow(stringResource(R.string.stats_calibrated), if (state.startTimeCalibrated) stringResource(R.string.action_yes) else stringResource(R.string.action_no),
             if (state.startTimeCalibrated) ColorGood else ColorWarning)
         StatRow(stringResource(R.string.stats_calibrations), state.dacCalibrationCount.toString())
@@ -211,6 +220,9 @@ fun StatsContent(
             if (state.gapsFilled > 0) ColorWarning else null)
         StatRow(stringResource(R.string.stats_overlaps), "${state.overlapsTrimmed} (${state.overlapTrimmedMs} ms)",
             if (state.overlapsTrimmed > 0) ColorWarning else null)
+        if (state.serverOverflowBps >= 0) {
+            StatRow(stringResource(R.string.stats_server_overflow), "${state.serverOverflowBps / 1000} KB/s", ColorBad)
+        }
 
         HorizontalDivider(modifier = Modifier.padding(vertical = 12.dp))
 
@@ -248,10 +260,43 @@ fun StatsContent(
             }
         }
 
+        // === TASKS (background jobs, for "stuck connecting" triage) ===
+        val tasks = taskStatusEntries(state.taskStatus)
+        if (tasks.isNotEmpty()) {
+            HorizontalDivider(modifier = Modifier.padding(vertical = 12.dp))
+            SectionHeader(stringResource(R.string.stats_section_tasks))
+            tasks.forEach { (task, status) ->
+                StatRow(taskLabel(task), status, if (status == "running" || status == "connected") ColorGood else null)
+            }
+        }
+
         Spacer(modifier = Modifier.height(32.dp))
     }
 }
 
+/** (task, status) pairs from the "task=status" lines in [StatsState.taskStatus]. */
+private fun taskStatusEntries(summary: String?): List<Pair<String, String>> =
+    summary?.lineSequence()
+        ?.mapNotNull { line ->
+            val i = line.indexOf('=')
+            if (i <= 0) null else line.substring(0, i) to line.substring(i + 1)
+        }
+        ?.toList()
+        ?: emptyList()
+
+/** Display label for a [com.sendspindroid.sendspin.SendSpin.getTaskStatus] key; unknown keys pass through. */
+@Composable
+private fun taskLabel(task: String): String = when (task) {
+    "transport" -> stringResource(R.string.stats_task_transport)
+    "handshake_timeout" -> stringResource(R.string.stats_task_handshake_timeout)
+    "stall_watchdog" -> stringResource(R.string.stats_stall_watchdog)
+    "reconnect" -> stringResource(R.string.stats_task_reconnect)
+    "position_ticker" -> stringResource(R.string.stats_task_position_ticker)
+    "time_sync" -> stringResource(R.string.stats_task_time_sync)
+    "scopes" -> stringResource(R.string.stats_task_scopes)
+    else -> task
+}
+
 /** Episode lines from the recorder's summary, dropping its header / empty marker. */
 private fun handoffEpisodeLines(summary: String?): List<String> =
     summary?.lineSequence()
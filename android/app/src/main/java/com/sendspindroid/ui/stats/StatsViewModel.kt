@@ -184,9 +184,15 @@ class StatsViewModel(application: Application) : AndroidViewModel(application) {
             lastDisconnectReason = bundle.getString("last_disconnect_reason", null),
             timeFilterStability = bundle.getDouble("time_filter_stability", 1.0),
             timeFilterConvergenceMs = bundle.getLong("time_filter_convergence_ms", 0L),
+            clockSyncUnstableJitterUs = bundle.getLong("clock_sync_unstable_jitter_us", -1L),
+            serverOverflowBps = bundle.getLong("server_overflow_bps", -1L),
+            audioStarted = bundle.getBoolean("audio_started", false),
 
             // Connection health (handoff episodes)
             handoffEpisodes = bundle.getString("handoff_episodes", null),
+
+            // Background task status
+            taskStatus = bundle.getString("task_status", null),
         )
     }
 
@@ -274,8 +280,16 @@ data class StatsState(
     val timeFilterStability: Double = 1.0,
     val timeFilterConvergenceMs: Long = 0L,
 
+    // Health warnings from SendSpin callbacks; -1 means none reported.
+    val clockSyncUnstableJitterUs: Long = -1L,
+    val serverOverflowBps: Long = -1L,
+    val audioStarted: Boolean = false,
+
     // Connection health: newline-delimited handoff-episode summary from the recorder.
     val handoffEpisodes: String? = null,
+
+    // Newline-delimited "task=status" lines from SendSpin.getTaskStatus().
+    val taskStatus: String? = null,
 ) {
     // Derived values
     val syncErrorMs: Double get() = syncErrorUs / 1000.0
@@ -0,0 +1,38 @@
+package com.sendspindroid.diagnostics
+
+import org.junit.Assert.assertEquals
+import org.junit.Assert.assertFalse
+import org.junit.Assert.assertTrue
+import org.junit.Test
+
+class MetricsTextTest {
+
+    @Test
+    fun `counters carry the total suffix and gauges do not`() {
+        val stats = mapOf<String, Any?>("chunks_dropped" to 3L, "rtt_us" to 1500L)
+
+        val lines = MetricsText.format(stats::get).lines()
+
+        assertTrue(lines.contains("# TYPE sendspin_audio_chunks_dropped counter"))
+        assertTrue(lines.contains("sendspin_audio_chunks_dropped_total 3"))
+        assertTrue(lines.contains("# TYPE sendspin_rtt_microseconds gauge"))
+        assertTrue(lines.contains("sendspin_rtt_microseconds 1500"))
+    }
+
+    @Test
+    fun `absent and non-numeric keys are skipped`() {
+        val stats = mapOf<String, Any?>("chunks_played" to 7, "sync_error_us" to "n/a")
+
+        val text = MetricsText.format(stats::get)
+
+        assertTrue(text.contains("sendspin_audio_chunks_played_total 7"))
+        assertFalse(text.contains("sync_error"))
+        assertFalse(text.contains("reconnect"))
+    }
+
+    @Test
+    fun `output ends with EOF even when empty`() {
+        assertEquals("# EOF\n", MetricsText.format { null })
+        assertTrue(MetricsText.format(mapOf("gaps_filled" to 1L)::get).endsWith("# EOF\n"))
+    }
+}
@@ -0,0 +1,55 @@
+package com.sendspindroid.e2e
+
+import com.sendspindroid.coordinator.TransportState
+import org.junit.Assert.*
+import org.junit.Test
+
+/**
+ * E2E: connecting over a caller-supplied transport via
+ * [com.sendspindroid.sendspin.SendSpin.connectWithTransport], without the
+ * reflection-based injection the other E2E tests use.
+ */
+class CallerSuppliedTransportTest : E2ETestBase() {
+
+    @Test
+    fun `handshake completes over the supplied transport`() {
+        client.connectWithTransport(fakeTransport)
+
+        assertEquals(
+            "SendSpin should call connect() on the supplied transport",
+            com.sendspindroid.sendspin.transport.TransportState.Connecting,
+            fakeTransport.state
+        )
+        assertTrue(client.connectionState.value is TransportState.Connecting)
+
+        fakeServer.completeHandshake()
+
+        assertTrue("client/hello should go over the supplied transport", fakeServer.clientSentHello())
+        assertTrue(client.isConnected)
+    }
+
+    @Test
+    fun `drop does not reconnect - supplied transport cannot be re-dialed`() {
+        client.connectWithTransport(fakeTransport)
+        fakeServer.completeHandshake()
+
+        fakeTransport.simulateClosed(code = 1006, reason = "tunnel closed")
+
+        assertFalse(client.isConnected)
+        assertTrue(
+            "State should be Idle, was: ${client.connectionState.value}",
+            client.connectionState.value is TransportState.Idle
+        )
+    }
+
+    @Test
+    fun `disconnect closes the supplied transport`() {
+        client.connectWithTransport(fakeTransport)
+        fakeServer.completeHandshake()
+
+        client.disconnect()
+
+        assertTrue(fakeTransport.closed)
+        assertEquals(1000, fakeTransport.closeCode)
+    }
+}
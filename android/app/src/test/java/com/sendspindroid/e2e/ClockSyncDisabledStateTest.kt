@@ -0,0 +1,38 @@
+package com.sendspindroid.e2e
+
+import org.junit.Assert.*
+import org.junit.Test
+
+/**
+ * E2E: with clock sync disabled the filter never converges, but playback
+ * free-runs without it, so client/state must report "synchronized"
+ * rather than staying on the pre-sync "error" for the whole session.
+ */
+class ClockSyncDisabledStateTest : E2ETestBase() {
+
+    private fun lastReportedSyncState(): String? =
+        fakeTransport.findSentMessages { it.contains("client/state") }
+            .lastOrNull()
+            ?.let { Regex("\"state\":\"([a-z_]+)\"").find(it)?.groupValues?.get(1) }
+
+    @Test
+    fun `disabled before connect reports synchronized on handshake`() {
+        client.clockSyncDisabled = true
+
+        connectAndHandshake()
+
+        assertEquals("synchronized", lastReportedSyncState())
+    }
+
+    @Test
+    fun `toggling while connected republishes the state`() {
+        connectAndHandshake()
+        assertEquals("Unsynced filter reports error", "error", lastReportedSyncState())
+
+        client.clockSyncDisabled = true
+        assertEquals("synchronized", lastReportedSyncState())
+
+        client.clockSyncDisabled = false
+        assertEquals("error", lastReportedSyncState())
+    }
+}
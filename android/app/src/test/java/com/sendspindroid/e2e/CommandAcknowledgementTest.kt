@@ -0,0 +1,59 @@
+package com.sendspindroid.e2e
+
+import kotlinx.coroutines.CoroutineStart
+import kotlinx.coroutines.async
+import kotlinx.coroutines.runBlocking
+import org.junit.Assert.*
+import org.junit.Test
+
+/**
+ * E2E: [com.sendspindroid.sendspin.SendSpin.sendCommandAndAwait] resolves when
+ * the server reflects the command, and reports false when it doesn't.
+ *
+ * The await is started undispatched so the command is on the wire before the
+ * fake server answers.
+ */
+class CommandAcknowledgementTest : E2ETestBase() {
+
+    @Test
+    fun `play is acknowledged by server state playing`() = runBlocking {
+        connectAndHandshake()
+        fakeServer.sendServerState(playbackState = "paused")
+
+        val result = async(start = CoroutineStart.UNDISPATCHED) {
+            client.sendCommandAndAwait("play", timeoutMs = 2_000)
+        }
+        assertTrue(fakeServer.clientSentCommand("play"))
+        fakeServer.sendServerState(playbackState = "playing")
+
+        assertTrue(result.await())
+    }
+
+    @Test
+    fun `next is acknowledged by a different track`() = runBlocking {
+        connectAndHandshake()
+        fakeServer.sendServerState(title = "First")
+
+        val result = async(start = CoroutineStart.UNDISPATCHED) {
+            client.sendCommandAndAwait("next", timeoutMs = 2_000)
+        }
+        fakeServer.sendServerState(title = "Second")
+
+        assertTrue(result.await())
+    }
+
+    @Test
+    fun `unanswered command times out as not acknowledged`() = runBlocking {
+        connectAndHandshake()
+        fakeServer.sendServerState(playbackState = "playing")
+
+        assertFalse(client.sendCommandAndAwait("pause", timeoutMs = 50))
+        assertTrue("Command should still have been sent", fakeServer.clientSentCommand("pause"))
+    }
+
+    @Test
+    fun `command while disconnected fails without sending`() = runBlocking {
+        assertFalse(client.sendCommandAndAwait("play", timeoutMs = 2_000))
+        assertFalse(fakeServer.clientSentCommand("play"))
+    }
+}
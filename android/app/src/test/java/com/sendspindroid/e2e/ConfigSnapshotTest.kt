@@ -0,0 +1,84 @@
+package com.sendspindroid.e2e
+
+import com.sendspindroid.UserSettings
+import com.sendspindroid.sendspin.SendSpin
+import com.sendspindroid.sendspin.protocol.SendSpinProtocol
+import com.sendspindroid.sendspin.protocol.timesync.TimeSyncManager
+import com.sendspindroid.sendspin.transport.BaseWebSocketTransport
+import io.mockk.every
+import kotlinx.serialization.json.Json
+import kotlinx.serialization.json.jsonArray
+import kotlinx.serialization.json.jsonObject
+import kotlinx.serialization.json.jsonPrimitive
+import org.junit.Assert.*
+import org.junit.Test
+
+/**
+ * E2E: [SendSpin.getConfigSnapshot] reports the resolved configuration for
+ * bug reports without leaking credentials or addresses.
+ */
+class ConfigSnapshotTest : E2ETestBase() {
+
+    @Test
+    fun `snapshot resolves options and redacts the auth token`() {
+        connectAndHandshake(
+            mode = SendSpin.ConnectionMode.PROXY,
+            serverAddress = "https://proxy.example.com/sendspin",
+            authToken = "secret-token"
+        )
+        client.sniffMislabeledBinary = true
+
+        val config = client.getConfigSnapshot()
+
+        assertEquals("proxy", config["connection_mode"])
+        assertEquals("[redacted]", config["auth_token"])
+        assertEquals("pcm", config["preferred_codec"])
+        assertEquals("true", config["sniff_mislabeled_binary"])
+        assertTrue(config.getValue("roles").contains("player@v1"))
+        assertTrue(config.getValue("buffer_capacity_bytes").toInt() > 0)
+
+        val text = config.entries.joinToString("\n") { "${it.key}=${it.value}" }
+        assertFalse("Token must not appear", text.contains("secret-token"))
+        assertFalse("Address must not appear", text.contains("proxy.example.com"))
+    }
+
+    @Test
+    fun `snapshot roles match client hello without artwork`() {
+        every { UserSettings.lowMemoryMode } returns true
+        injectTransportAndConnect()
+        fakeTransport.simulateConnected()
+
+        val roles = client.getConfigSnapshot().getValue("roles").split(",")
+        val hello = fakeTransport.findSentMessages { it.contains("client/hello") }.single()
+        val advertised = Json.parseToJsonElement(hello).jsonObject
+            .getValue("payload").jsonObject
+            .getValue("supported_roles").jsonArray
+            .map { it.jsonPrimitive.content }
+
+        assertFalse(roles.contains(SendSpinProtocol.Roles.ARTWORK))
+        assertEquals(advertised, roles)
+    }
+
+    @Test
+    fun `invalid numeric options fall back to defaults`() {
+        client.clockSyncIntervalMs = -1
+        client.maxFrameBytes = 0
+        client.maxArtworkBytes = -5
+        client.setPositionUpdateInterval(-1)
+
+        val config = client.getConfigSnapshot()
+
+        assertEquals(TimeSyncManager.DEFAULT_CONVERGED_INTERVAL_MS.toString(), config["clock_sync_interval_ms"])
+        assertEquals(BaseWebSocketTransport.DEFAULT_MAX_FRAME_BYTES.toString(), config["max_frame_bytes"])
+        assertEquals(SendSpinProtocol.Artwork.MAX_BYTES.toString(), config["max_artwork_bytes"])
+        assertEquals("Negative interval leaves the ticker off", "none", client.getTaskStatus()["position_ticker"])
+    }
+
+    @Test
+    fun `snapshot without credentials says none`() {
+        val config = client.getConfigSnapshot()
+
+        assertEquals("none", config["auth_token"])
+        assertEquals("none", config["proxy_fallback"])
+    }
+}
@@ -1,6 +1,7 @@
 package com.sendspindroid.e2e
 
 import com.sendspindroid.coordinator.TransportState
+import com.sendspindroid.model.PlaybackStateType
 import com.sendspindroid.sendspin.SendSpin
 import io.mockk.verify
 import org.junit.Assert.*
@@ -55,7 +56,7 @@ class DiscoverConnectPlayDisconnectTest : E2ETestBase() {
                 "", 180000, 5000, 1000
             )
         }
-        verify { mockCallback.onStateChanged("playing") }
+        verify { mockCallback.onStateChanged(PlaybackStateType.PLAYING) }
 
         // Server sends audio chunks
         val silence = fakeServer.generateSilence(durationMs = 100)
@@ -124,6 +125,26 @@ class DiscoverConnectPlayDisconnectTest : E2ETestBase() {
         verify(exactly = 5) { mockCallback.onAudioChunk(any(), any()) }
     }
 
+    @Test
+    fun `audio started fires once per stream, on the first chunk`() {
+        connectAndHandshake()
+        val silence = fakeServer.generateSilence(durationMs = 50)
+
+        fakeServer.sendStreamStart()
+        fakeServer.sendServerState(playbackState = "playing")
+        verify(exactly = 0) { mockCallback.onAudioStarted() }
+
+        fakeServer.sendAudioChunk(timestampMicros = 1_000_000L, audioData = silence)
+        fakeServer.sendAudioChunk(timestampMicros = 1_050_000L, audioData = silence)
+        verify(exactly = 1) { mockCallback.onAudioStarted() }
+
+        // A new stream arms it again
+        fakeServer.sendStreamEnd()
+        fakeServer.sendStreamStart()
+        fakeServer.sendAudioChunk(timestampMicros = 2_000_000L, audioData = silence)
+        verify(exactly = 2) { mockCallback.onAudioStarted() }
+    }
+
     @Test
     fun `artwork delivered and cleared correctly`() {
         connectAndHandshake()
@@ -144,11 +165,11 @@ class DiscoverConnectPlayDisconnectTest : E2ETestBase() {
 
         // Playing
         fakeServer.sendServerState(playbackState = "playing")
-        verify { mockCallback.onStateChanged("playing") }
+        verify { mockCallback.onStateChanged(PlaybackStateType.PLAYING) }
 
         // Stopped
         fakeServer.sendServerState(playbackState = "stopped")
-        verify { mockCallback.onStateChanged("stopped") }
+        verify { mockCallback.onStateChanged(PlaybackStateType.STOPPED) }
     }
 
     @Test
@@ -0,0 +1,50 @@
+package com.sendspindroid.e2e
+
+import com.sendspindroid.coordinator.TransportState
+import io.mockk.verify
+import org.junit.Assert.*
+import org.junit.Test
+import java.net.SocketException
+
+/**
+ * E2E: connecting by address with no discovery ever run, as for a server
+ * reached over a VPN or the internet where mDNS does not propagate.
+ *
+ * SendSpin has no discovery of its own; these tests pin that the whole
+ * connect -> keepalive -> reconnect flow works from an address alone.
+ */
+class DiscoveryFreeConnectTest : E2ETestBase() {
+
+    private val vpnAddress = "10.8.0.1:8927"
+
+    @Test
+    fun `connect and handshake by address alone`() {
+        connectAndHandshake(serverAddress = vpnAddress)
+
+        assertTrue(client.isConnected)
+        assertEquals(vpnAddress, client.getServerAddress())
+        verify(exactly = 0) { mockCallback.onServerDiscovered(any(), any()) }
+    }
+
+    @Test
+    fun `keepalive runs without discovery`() {
+        connectAndHandshake(serverAddress = vpnAddress)
+
+        assertEquals("running", client.getTaskStatus()["stall_watchdog"])
+    }
+
+    @Test
+    fun `drop reconnects to the same address`() {
+        connectAndHandshake(serverAddress = vpnAddress)
+
+        fakeTransport.simulateFailure(SocketException("VPN tunnel reset"), isRecoverable = true)
+
+        assertEquals(1, client.getReconnectAttempts())
+        assertTrue(
+            "State should be Connecting during reconnection, was: ${client.connectionState.value}",
+            client.connectionState.value is TransportState.Connecting
+        )
+        assertEquals("Reconnect should re-dial the original address", vpnAddress, client.getServerAddress())
+        verify(exactly = 0) { mockCallback.onServerDiscovered(any(), any()) }
+    }
+}
@@ -0,0 +1,59 @@
+package com.sendspindroid.e2e
+
+import com.sendspindroid.sendspin.SendSpin
+import io.mockk.verify
+import org.junit.Assert.*
+import org.junit.Test
+import java.util.concurrent.atomic.AtomicLong
+
+/**
+ * E2E: [SendSpin.flushBuffers] drops local buffers through
+ * [SendSpin.Callback.onFlushBuffers] while the stream keeps running, so
+ * the stall watchdog stays on its streaming threshold.
+ */
+class FlushBuffersTest : E2ETestBase() {
+
+    /** Pretend nothing has arrived for [silentMs], then run one watchdog check. */
+    private fun runStallCheckAfterSilence(silentMs: Long) {
+        getField<AtomicLong>(client, "lastByteReceivedAtMs").set(System.currentTimeMillis() - silentMs)
+        val checkStall = SendSpin::class.java.getDeclaredMethod("checkStall")
+        checkStall.isAccessible = true
+        checkStall.invoke(client)
+    }
+
+    @Test
+    fun `flush keeps the stream active`() {
+        connectAndHandshake()
+        fakeServer.sendStreamStart()
+
+        client.flushBuffers()
+
+        verify(exactly = 1) { mockCallback.onFlushBuffers() }
+        verify(exactly = 0) { mockCallback.onStreamClear() }
+        assertTrue(getAtomicBoolean(client, "streamActive"))
+    }
+
+    @Test
+    fun `stall watchdog keeps the streaming threshold after a flush`() {
+        connectAndHandshake()
+        fakeServer.sendStreamStart()
+        client.flushBuffers()
+
+        // Past the 7 s streaming threshold, well short of the 20 s idle one
+        runStallCheckAfterSilence(10_000)
+
+        assertTrue("Stalled stream must still be detected", fakeTransport.closed)
+        assertNotEquals(1000, fakeTransport.closeCode)
+    }
+
+    @Test
+    fun `audio after a flush is still delivered`() {
+        connectAndHandshake()
+        fakeServer.sendStreamStart()
+        client.flushBuffers()
+
+        fakeServer.sendAudioChunk(1_000_000L, ByteArray(64))
+
+        verify(exactly = 1) { mockCallback.onAudioChunk(any(), any()) }
+    }
+}
@@ -0,0 +1,201 @@
+package com.sendspindroid.e2e
+
+import com.sendspindroid.coordinator.TransportState
+import com.sendspindroid.sendspin.SendSpin
+import org.junit.Assert.*
+import org.junit.Test
+
+/**
+ * E2E: handshake failure paths.
+ *
+ * Between the socket opening and server/hello arriving the client must not
+ * report itself connected, must not start talking protocol beyond
+ * client/hello, and must end up in a well-defined state (reconnecting or
+ * Idle) when the server misbehaves.
+ *
+ * Flow for each case:
+ * 1. Inject transport, simulate connection open (client/hello goes out)
+ * 2. Server misbehaves instead of sending a valid server/hello
+ * 3. Verify: not Ready, handshake incomplete, nothing but client/hello sent,
+ *    and the dead transport released rather than left attached
+ */
+class HandshakeFailurePathsTest : E2ETestBase() {
+
+    @Test
+    fun `server closes abnormally mid-handshake - not connected, no stray messages`() {
+        client.selfReconnectEnabled = false
+        injectTransportAndConnect()
+        fakeTransport.simulateConnected()
+        assertTrue("client/hello should be sent", fakeTransport.hasSentMessageContaining("client/hello"))
+
+        fakeTransport.simulateClosed(code = 1006, reason = "server went away")
+
+        assertFalse("Client must not report connected", client.isConnected)
+        assertFalse("Handshake must not be complete", handshakeComplete())
+        assertTrue(
+            "State should be Idle with self-reconnect off, was: ${client.connectionState.value}",
+            client.connectionState.value is TransportState.Idle
+        )
+        assertEquals(
+            "Only client/hello may be sent before server/hello",
+            listOf("client/hello"),
+            sentTypes()
+        )
+    }
+
+    @Test
+    fun `server closes normally mid-handshake - session ends without reconnect`() {
+        injectTransportAndConnect()
+        fakeTransport.simulateConnected()
+
+        fakeTransport.simulateClosed(code = 1000, reason = "rejected")
+
+        assertFalse(client.isConnected)
+        assertTrue(
+            "Normal closure before hello should not reconnect, was: ${client.connectionState.value}",
+            client.connectionState.value is TransportState.Idle
+        )
+    }
+
+    @Test
+    fun `server sends wrong message type first - handshake stays pending`() {
+        injectTransportAndConnect()
+        fakeTransport.simulateConnected()
+
+        // A server/state where server/hello should be
+        fakeServer.sendServerState(playbackState = "playing")
+
+        assertFalse("Client must not report connected", client.isConnected)
+        assertFalse("Handshake must not be complete", handshakeComplete())
+        assertTrue(
+            "State should still be Connecting, was: ${client.connectionState.value}",
+            client.connectionState.value is TransportState.Connecting
+        )
+        assertFalse(
+            "No client/state before server/hello",
+            fakeTransport.hasSentMessageContaining("client/state")
+        )
+
+        // A late but valid server/hello still completes the handshake
+        fakeServer.sendServerHello()
+        assertTrue("Client should be connected after server/hello", client.isConnected)
+    }
+
+    @Test
+    fun `server hello without payload does not complete handshake`() {
+        injectTransportAndConnect()
+        fakeTransport.simulateConnected()
+
+        fakeTransport.simulateTextMessage("""{"type":"server/hello"}""")
+
+        assertFalse(client.isConnected)
+        assertFalse(handshakeComplete())
+        assertFalse(fakeTransport.hasSentMessageContaining("client/state"))
+    }
+
+    @Test
+    fun `garbage before server hello is ignored`() {
+        injectTransportAndConnect()
+        fakeTransport.simulateConnected()
+
+        fakeTransport.simulateTextMessage("not json at all")
+        fakeTransport.simulateTextMessage("""{"payload":{}}""")
+
+        assertFalse(client.isConnected)
+        assertTrue(client.connectionState.value is TransportState.Connecting)
+
+        fakeServer.sendServerHello()
+        assertTrue(client.isConnected)
+    }
+
+    @Test
+    fun `server drops after client hello - transport released and state reset`() {
+        client.selfReconnectEnabled = false
+        injectTransportAndConnect()
+        fakeTransport.simulateConnected()
+        assertNotNull("Handshake deadline should be armed", handshakeTimeoutJob())
+
+        fakeTransport.simulateClosed(code = 1006, reason = "dropped")
+
+        assertTrue("Dead transport must be destroyed", fakeTransport.destroyed)
+        assertNull("Listener must be detached", fakeTransport.getListener())
+        assertNull("Client must drop its transport reference", getField<Any?>(client, "transport"))
+        assertNull("Handshake deadline must be cleared", handshakeTimeoutJob())
+        assertFalse(handshakeComplete())
+    }
+
+    @Test
+    fun `transport failure mid-handshake releases the transport`() {
+        client.selfReconnectEnabled = false
+        injectTransportAndConnect()
+        fakeTransport.simulateConnected()
+
+        fakeTransport.simulateFailure(java.net.SocketException("connection reset"))
+
+        assertTrue(fakeTransport.destroyed)
+        assertNull(getField<Any?>(client, "transport"))
+        assertTrue(client.connectionState.value is TransportState.Idle)
+    }
+
+    @Test
+    fun `server never replies - handshake timeout closes and releases the transport`() {
+        client.selfReconnectEnabled = false
+        injectTransportAndConnect()
+        fakeTransport.simulateConnected()
+
+        invokeHandshakeTimeout()
+
+        assertEquals("Timeout should close with Going Away", 1001, fakeTransport.closeCode)
+        assertTrue(fakeTransport.destroyed)
+        assertNull(getField<Any?>(client, "transport"))
+        assertFalse(client.isConnected)
+        assertTrue(
+            "State should be Idle with self-reconnect off, was: ${client.connectionState.value}",
+            client.connectionState.value is TransportState.Idle
+        )
+        assertEquals("handshake timeout", client.getLastDisconnectReason())
+    }
+
+    @Test
+    fun `server never replies - handshake timeout reconnects when allowed`() {
+        injectTransportAndConnect()
+        fakeTransport.simulateConnected()
+
+        invokeHandshakeTimeout()
+
+        assertTrue(fakeTransport.destroyed)
+        assertTrue(
+            "State should be Connecting (reconnect scheduled), was: ${client.connectionState.value}",
+            client.connectionState.value is TransportState.Connecting
+        )
+    }
+
+    @Test
+    fun `handshake timeout after server hello is a no-op`() {
+        connectAndHandshake()
+        assertNull("Deadline cleared on server/hello", handshakeTimeoutJob())
+
+        invokeHandshakeTimeout()
+
+        assertTrue(client.isConnected)
+        assertFalse(fakeTransport.closed)
+    }
+
+    // --- helpers ---
+
+    private fun handshakeTimeoutJob(): Any? = getField(client, "handshakeTimeoutJob")
+
+    private fun invokeHandshakeTimeout() {
+        val method = SendSpin::class.java.getDeclaredMethod("onHandshakeTimeout")
+        method.isAccessible = true
+        method.invoke(client)
+    }
+
+    private fun handshakeComplete(): Boolean =
+        getField(client, "handshakeComplete", clazz = client::class.java.superclass)
+
+    private fun sentTypes(): List<String> =
+        fakeTransport.sentTextMessages.mapNotNull {
+            Regex("\"type\":\"([^\"]+)\"").find(it)?.groupValues?.get(1)
+        }
+}
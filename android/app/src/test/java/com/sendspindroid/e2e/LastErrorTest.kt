@@ -0,0 +1,66 @@
+package com.sendspindroid.e2e
+
+import com.sendspindroid.coordinator.FailureReason
+import com.sendspindroid.coordinator.TransportState
+import org.junit.Assert.*
+import org.junit.Test
+import java.net.UnknownHostException
+
+/**
+ * E2E: [com.sendspindroid.sendspin.SendSpin.getLastError] keeps the last
+ * connection failure for a UI that attaches after it was reported, and a
+ * successful handshake clears it.
+ */
+class LastErrorTest : E2ETestBase() {
+
+    @Test
+    fun `failure is kept until the next successful handshake`() {
+        assertNull("No error before any connection", client.getLastError())
+
+        injectTransportAndConnect()
+        fakeTransport.simulateConnected()
+        fakeTransport.simulateFailure(UnknownHostException("nas.local"), isRecoverable = false)
+
+        assertTrue(client.connectionState.value is TransportState.Failed)
+        val error = client.getLastError()
+        assertNotNull("Failure should be stored", error)
+        assertEquals(FailureReason.HandshakeFailed, error!!.reason)
+        assertEquals("nas.local", error.message)
+        assertTrue("Timestamp should be set", error.atMs > 0)
+
+        connectAndHandshake()
+
+        assertTrue(client.isConnected)
+        assertNull("Successful connect should clear the error", client.getLastError())
+    }
+
+    @Test
+    fun `abnormal close is recorded as a transient error`() {
+        client.selfReconnectEnabled = false
+        connectAndHandshake()
+
+        fakeTransport.simulateClosed(code = 1006, reason = "server went away")
+
+        assertEquals("server went away", client.getLastError()?.message)
+        assertEquals(FailureReason.TransientNetwork, client.getLastError()?.reason)
+    }
+
+    @Test
+    fun `handshake timeout is recorded`() {
+        client.selfReconnectEnabled = false
+        client.handshakeTimeoutMs = 50
+        injectTransportAndConnect()
+        fakeTransport.simulateConnected()
+
+        // The deadline runs on the client's timer thread; allow generous slack
+        val deadline = System.currentTimeMillis() + 5_000
+        while (client.getLastError() == null && System.currentTimeMillis() < deadline) {
+            Thread.sleep(10)
+        }
+
+        val error = client.getLastError()
+        assertNotNull("Timeout should be stored", error)
+        assertEquals(FailureReason.HandshakeFailed, error!!.reason)
+        assertTrue(error.message.contains("server/hello"))
+    }
+}
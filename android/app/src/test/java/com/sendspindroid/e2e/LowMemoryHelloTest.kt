@@ -0,0 +1,43 @@
+package com.sendspindroid.e2e
+
+import com.sendspindroid.UserSettings
+import com.sendspindroid.sendspin.protocol.SendSpinProtocol
+import io.mockk.every
+import kotlinx.serialization.json.Json
+import kotlinx.serialization.json.jsonArray
+import kotlinx.serialization.json.jsonObject
+import kotlinx.serialization.json.jsonPrimitive
+import org.junit.Assert.*
+import org.junit.Test
+
+/**
+ * E2E: low-memory mode leaves artwork@v1 out of client/hello, so the
+ * server never pushes images the app would drop anyway.
+ */
+class LowMemoryHelloTest : E2ETestBase() {
+
+    private fun advertisedRoles(): List<String> {
+        injectTransportAndConnect()
+        fakeTransport.simulateConnected()
+        val hello = fakeTransport.findSentMessages { it.contains("client/hello") }.single()
+        return Json.parseToJsonElement(hello).jsonObject
+            .getValue("payload").jsonObject
+            .getValue("supported_roles").jsonArray
+            .map { it.jsonPrimitive.content }
+    }
+
+    @Test
+    fun `low-memory hello omits artwork`() {
+        every { UserSettings.lowMemoryMode } returns true
+
+        val roles = advertisedRoles()
+
+        assertFalse(roles.contains(SendSpinProtocol.Roles.ARTWORK))
+        assertTrue(roles.contains(SendSpinProtocol.Roles.PLAYER))
+    }
+
+    @Test
+    fun `default hello advertises artwork`() {
+        assertTrue(advertisedRoles().contains(SendSpinProtocol.Roles.ARTWORK))
+    }
+}
@@ -0,0 +1,53 @@
+package com.sendspindroid.e2e
+
+import kotlinx.serialization.json.Json
+import kotlinx.serialization.json.int
+import kotlinx.serialization.json.jsonObject
+import kotlinx.serialization.json.jsonPrimitive
+import org.junit.Assert.*
+import org.junit.Test
+
+/**
+ * E2E: reporting new output capabilities mid-stream (e.g. switching to a
+ * 16-bit-only Bluetooth headset) asks the server for a playable format via
+ * stream/request-format instead of waiting for a reconnect.
+ */
+class OutputCapabilitiesChangeTest : E2ETestBase() {
+
+    private fun formatRequests(): List<String> =
+        fakeTransport.findSentMessages { it.contains("stream/request-format") }
+
+    @Test
+    fun `unsupported active stream requests a playable format`() {
+        connectAndHandshake()
+        fakeServer.sendStreamStart(codec = "pcm", sampleRate = 48000, channels = 2, bitDepth = 24)
+
+        client.setOutputCapabilities(sampleRates = listOf(48000), bitDepths = listOf(16), channels = 2)
+
+        val player = Json.parseToJsonElement(formatRequests().single()).jsonObject
+            .getValue("payload").jsonObject
+            .getValue("player").jsonObject
+        assertEquals("pcm", player.getValue("codec").jsonPrimitive.content)
+        assertEquals(48000, player.getValue("sample_rate").jsonPrimitive.int)
+        assertEquals(16, player.getValue("bit_depth").jsonPrimitive.int)
+    }
+
+    @Test
+    fun `supported active stream is left alone`() {
+        connectAndHandshake()
+        fakeServer.sendStreamStart(codec = "pcm", sampleRate = 48000, channels = 2, bitDepth = 16)
+
+        client.setOutputCapabilities(sampleRates = listOf(44100, 48000), bitDepths = listOf(16), channels = 2)
+
+        assertTrue(formatRequests().isEmpty())
+    }
+
+    @Test
+    fun `capabilities without a stream only affect the next hello`() {
+        connectAndHandshake()
+
+        client.setOutputCapabilities(sampleRates = listOf(48000), bitDepths = listOf(16), channels = 2)
+
+        assertTrue(formatRequests().isEmpty())
+    }
+}
@@ -0,0 +1,126 @@
+package com.sendspindroid.e2e
+
+import io.mockk.clearMocks
+import io.mockk.verify
+import kotlinx.coroutines.test.StandardTestDispatcher
+import kotlinx.coroutines.test.TestCoroutineScheduler
+import kotlinx.coroutines.test.TestScope
+import org.junit.Assert.*
+import org.junit.Test
+
+/**
+ * E2E: the opt-in position ticker from
+ * [com.sendspindroid.sendspin.SendSpin.setPositionUpdateInterval].
+ *
+ * The ticker runs on a [TestCoroutineScheduler], so ticks are driven by
+ * virtual time instead of sleeps.
+ */
+class PositionUpdateTickerTest : E2ETestBase() {
+
+    private val scheduler = TestCoroutineScheduler()
+
+    override fun setUp() {
+        super.setUp()
+        client.positionTickerScope = TestScope(StandardTestDispatcher(scheduler))
+    }
+
+    /** Two accepted measurements make the filter ready (offset 10 ms). */
+    private fun syncClock() {
+        val filter = client.getTimeFilter()
+        filter.addMeasurement(10_000L, 3000L, 1_000_000L)
+        filter.addMeasurement(10_000L, 3000L, 2_000_000L)
+    }
+
+    private fun advance(ms: Long) {
+        scheduler.advanceTimeBy(ms)
+        scheduler.runCurrent()
+    }
+
+    @Test
+    fun `ticks while playing and stops when paused`() {
+        connectAndHandshake()
+        syncClock()
+        client.setPositionUpdateInterval(20)
+
+        fakeServer.sendServerState(playbackState = "playing", positionMs = 42_000)
+        advance(40)
+        verify(exactly = 3) { mockCallback.onPositionUpdate(42_000) }
+
+        fakeServer.sendServerState(playbackState = "paused", positionMs = 42_000)
+        advance(0)
+        clearMocks(mockCallback, answers = false)
+        advance(100)
+        verify(exactly = 0) { mockCallback.onPositionUpdate(any()) }
+    }
+
+    @Test
+    fun `off by default`() {
+        connectAndHandshake()
+        fakeServer.sendServerState(playbackState = "playing")
+
+        advance(100)
+        verify(exactly = 0) { mockCallback.onPositionUpdate(any()) }
+        assertEquals("none", client.getTaskStatus()["position_ticker"])
+    }
+
+    @Test
+    fun `interval zero turns the ticker off`() {
+        connectAndHandshake()
+        syncClock()
+        client.setPositionUpdateInterval(20)
+        fakeServer.sendServerState(playbackState = "playing")
+        advance(0)
+        verify(exactly = 1) { mockCallback.onPositionUpdate(any()) }
+
+        client.setPositionUpdateInterval(0)
+        clearMocks(mockCallback, answers = false)
+        advance(100)
+        verify(exactly = 0) { mockCallback.onPositionUpdate(any()) }
+    }
+
+    @Test
+    fun `skips ticks until the time filter is ready`() {
+        connectAndHandshake()
+        client.setPositionUpdateInterval(20)
+        fakeServer.sendServerState(playbackState = "playing", positionMs = 42_000)
+
+        advance(100)
+        verify(exactly = 0) { mockCallback.onPositionUpdate(any()) }
+
+        syncClock()
+        advance(20)
+        verify(atLeast = 1) { mockCallback.onPositionUpdate(42_000) }
+    }
+
+    @Test
+    fun `clock sync disabled never ticks`() {
+        client.clockSyncDisabled = true
+        connectAndHandshake()
+        client.setPositionUpdateInterval(20)
+        fakeServer.sendServerState(playbackState = "playing", positionMs = 42_000)
+
+        advance(100)
+        verify(exactly = 0) { mockCallback.onPositionUpdate(any()) }
+    }
+
+    @Test
+    fun `stops on disconnect and restarts after the next handshake`() {
+        client.setPositionUpdateInterval(20)
+        assertEquals("Not started before the handshake", "none", client.getTaskStatus()["position_ticker"])
+
+        connectAndHandshake()
+        assertEquals("running", client.getTaskStatus()["position_ticker"])
+
+        fakeTransport.simulateClosed()
+        assertEquals("none", client.getTaskStatus()["position_ticker"])
+        clearMocks(mockCallback, answers = false)
+        advance(100)
+        verify(exactly = 0) { mockCallback.onPositionUpdate(any()) }
+
+        connectAndHandshake()
+        syncClock()
+        fakeServer.sendServerState(playbackState = "playing", positionMs = 7_000)
+        advance(20)
+        verify(exactly = 2) { mockCallback.onPositionUpdate(7_000) }
+    }
+}
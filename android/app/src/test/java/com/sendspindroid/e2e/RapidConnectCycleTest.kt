@@ -0,0 +1,113 @@
+package com.sendspindroid.e2e
+
+import com.sendspindroid.coordinator.TransportState
+import com.sendspindroid.sendspin.transport.SendSpinTransport
+import org.junit.Assert.*
+import org.junit.Test
+
+/**
+ * E2E: rapid connect/disconnect/connect cycles, as Android lifecycle churn
+ * produces them.
+ *
+ * Each cycle leaves behind a listener from the superseded connection. Late
+ * events from those listeners (a close, a server/hello still in flight) must
+ * not touch the current connection's state.
+ */
+class RapidConnectCycleTest : E2ETestBase() {
+
+    private val serverHello =
+        """{"type":"server/hello","payload":{"name":"Old","server_id":"old-id","protocol_version":1,"active_roles":["player@v1"]}}"""
+
+    @Test
+    fun `stale events from superseded connections are ignored`() {
+        val stale = cycle(times = 50)
+
+        val current = FakeTransport()
+        val server = FakeSendSpinServer(current)
+        client.connectWithTransport(current)
+
+        // Last gasps from every old connection, before the new handshake
+        stale.forEach {
+            it.onConnected()
+            it.onMessage(serverHello)
+            it.onClosed(1006, "late close")
+            it.onFailure(java.net.SocketException("late failure"), isRecoverable = true)
+        }
+        assertTrue(
+            "Stale events must not move the new connection, was: ${client.connectionState.value}",
+            client.connectionState.value is TransportState.Connecting
+        )
+        assertFalse("A stale server/hello must not complete the handshake", client.isConnected)
+
+        server.completeHandshake()
+        assertTrue(client.isConnected)
+        assertEquals("TestServer", client.getServerName())
+
+        // ...and after it
+        stale.forEach { it.onClosed(1006, "late close") }
+        assertTrue("Stale close must not drop the live connection", client.isConnected)
+    }
+
+    @Test
+    fun `only the current transport sends client hello`() {
+        val stale = cycle(times = 10)
+        val current = FakeTransport()
+        client.connectWithTransport(current)
+
+        stale.forEach { it.onConnected() }
+        assertTrue(current.sentTextMessages.isEmpty())
+
+        current.simulateConnected()
+        assertEquals(1, current.sentTextMessages.count { it.contains("client/hello") })
+    }
+
+    @Test
+    fun `late events after user disconnect are ignored`() {
+        val transport = FakeTransport()
+        val server = FakeSendSpinServer(transport)
+        client.connectWithTransport(transport)
+        server.completeHandshake()
+        val listener = transport.getListener()!!
+
+        client.disconnect()
+        listener.onClosed(1006, "late close")
+
+        assertTrue(client.connectionState.value is TransportState.Idle)
+    }
+
+    @Test
+    fun `connection generation advances on connect and disconnect only`() {
+        val start = client.getConnectionGeneration()
+
+        val transport = FakeTransport()
+        val server = FakeSendSpinServer(transport)
+        client.connectWithTransport(transport)
+        val connected = client.getConnectionGeneration()
+        assertTrue("Connect should advance the generation", connected > start)
+
+        server.completeHandshake()
+        server.sendServerState()
+        assertEquals("Events on the live connection keep the generation", connected, client.getConnectionGeneration())
+
+        client.disconnect()
+        assertTrue("Disconnect should advance the generation", client.getConnectionGeneration() > connected)
+    }
+
+    /**
+     * Connect [times] times, alternating between an explicit disconnect and
+     * connecting straight over the previous attempt; return the listeners left
+     * behind by every superseded connection.
+     */
+    private fun cycle(times: Int): List<SendSpinTransport.Listener> =
+        (0 until times).map { i ->
+            val transport = FakeTransport()
+            client.connectWithTransport(transport)
+            val listener = transport.getListener()!!
+            if (i % 2 == 0) {
+                client.disconnect()
+            } else {
+                transport.simulateConnected()
+            }
+            listener
+        }
+}
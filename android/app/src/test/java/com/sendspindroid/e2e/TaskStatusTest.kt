@@ -0,0 +1,49 @@
+package com.sendspindroid.e2e
+
+import org.junit.Assert.*
+import org.junit.Test
+
+/**
+ * E2E: [com.sendspindroid.sendspin.SendSpin.getTaskStatus] reports what each
+ * background task is doing, for the stats screen's TASKS section.
+ */
+class TaskStatusTest : E2ETestBase() {
+
+    @Test
+    fun `keys are stable and in display order`() {
+        assertEquals(
+            listOf(
+                "transport", "handshake_timeout", "stall_watchdog", "reconnect",
+                "position_ticker", "time_sync", "scopes",
+            ),
+            client.getTaskStatus().keys.toList()
+        )
+    }
+
+    @Test
+    fun `idle client reports no transport and no jobs`() {
+        val status = client.getTaskStatus()
+
+        assertEquals("none", status["transport"])
+        assertEquals("none", status["handshake_timeout"])
+        assertEquals("none", status["reconnect"])
+        assertEquals("active", status["scopes"])
+    }
+
+    @Test
+    fun `task status shows pending handshake deadline, then clears it`() {
+        injectTransportAndConnect()
+        fakeTransport.simulateConnected()
+
+        val pending = client.getTaskStatus()
+        assertEquals("connected", pending["transport"])
+        assertEquals("running", pending["handshake_timeout"])
+        assertEquals("none", pending["reconnect"])
+
+        fakeServer.sendServerHello()
+
+        val ready = client.getTaskStatus()
+        assertEquals("none", ready["handshake_timeout"])
+        assertEquals("active", ready["scopes"])
+    }
+}
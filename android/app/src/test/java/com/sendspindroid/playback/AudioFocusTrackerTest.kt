@@ -0,0 +1,82 @@
+package com.sendspindroid.playback
+
+import org.junit.Assert.*
+import org.junit.Test
+
+class AudioFocusTrackerTest {
+
+    private val tracker = AudioFocusTracker()
+
+    @Test
+    fun `transient loss then gain resumes`() {
+        tracker.onTransientLoss(playing = true)
+
+        assertTrue(tracker.isLostTransiently)
+        assertTrue(tracker.wasPlayingBeforeLoss)
+        assertTrue(tracker.onGain(serverPlaying = true))
+        assertFalse(tracker.isLostTransiently)
+    }
+
+    @Test
+    fun `pause during a loss does not resume`() {
+        tracker.onTransientLoss(playing = true)
+        tracker.onPaused()
+
+        assertFalse(tracker.wasPlayingBeforeLoss)
+        assertFalse(tracker.onGain(serverPlaying = true))
+    }
+
+    @Test
+    fun `permanent loss does not resume`() {
+        tracker.onTransientLoss(playing = true)
+        tracker.onPermanentLoss()
+
+        assertFalse(tracker.isLostTransiently)
+        assertFalse(tracker.onGain(serverPlaying = true))
+    }
+
+    @Test
+    fun `gain does not resume when the server stopped meanwhile`() {
+        tracker.onTransientLoss(playing = true)
+
+        assertFalse(tracker.onGain(serverPlaying = false))
+    }
+
+    @Test
+    fun `loss while paused does not resume`() {
+        tracker.onTransientLoss(playing = false)
+
+        assertFalse(tracker.onGain(serverPlaying = true))
+    }
+
+    @Test
+    fun `play during a loss is deferred until gain`() {
+        tracker.onTransientLoss(playing = false)
+
+        assertTrue("Resume waits for focus", tracker.onPlayRequested())
+        assertTrue(tracker.onGain(serverPlaying = true))
+    }
+
+    @Test
+    fun `play with focus is not deferred`() {
+        assertFalse(tracker.onPlayRequested())
+        assertFalse(tracker.wasPlayingBeforeLoss)
+    }
+
+    @Test
+    fun `repeated transient loss keeps the original state`() {
+        tracker.onTransientLoss(playing = true)
+        // Second loss arrives after we already paused
+        tracker.onTransientLoss(playing = false)
+
+        assertTrue(tracker.onGain(serverPlaying = true))
+    }
+
+    @Test
+    fun `reset forgets the pending resume`() {
+        tracker.onTransientLoss(playing = true)
+        tracker.reset()
+
+        assertFalse(tracker.onGain(serverPlaying = true))
+    }
+}
@@ -251,6 +251,63 @@ class SyncAudioPlayerIntegrationTest {
         )
     }
 
+    @Test
+    fun `free-running queues chunks before time sync is ready`() {
+        val timeFilter = mockk<SendspinTimeFilter>(relaxed = true)
+        every { timeFilter.isReady } returns false
+
+        val player = SyncAudioPlayer(
+            timeFilter = timeFilter,
+            sampleRate = sampleRate,
+            channels = channels,
+            bitDepth = bitDepth,
+            nowNs = nowNs,
+            sinkFactory = { _, _, _, _ -> FakeAudioSink() },
+        )
+        val chunk = ByteArray(4_800 * 4) // 100ms
+
+        player.queueChunk(1_000_000L, chunk)
+        assertEquals("Synced mode holds chunks until the filter is ready", 0L, player.getBufferedDurationMs())
+
+        player.setFreeRunning(true, startBufferMs = 1_000L)
+        player.queueChunk(1_100_000L, chunk)
+
+        assertEquals(200L, player.getBufferedDurationMs())
+        assertEquals(PlaybackState.WAITING_FOR_START, player.getPlaybackState())
+    }
+
+    @Test
+    fun `free-running ignores sync mute`() {
+        val timeFilter = mockk<SendspinTimeFilter>(relaxed = true)
+        val fakeSink = FakeAudioSink()
+        val player = SyncAudioPlayer(
+            timeFilter = timeFilter,
+            sampleRate = sampleRate,
+            channels = channels,
+            bitDepth = bitDepth,
+            nowNs = nowNs,
+            sinkFactory = { _, _, _, _ -> fakeSink },
+        )
+        setField(player, "audioSink", fakeSink)
+        val bytesPerFrame = channels * (bitDepth / 8)
+        setField(player, "lastOutputFrame", ByteArray(bytesPerFrame))
+        setField(player, "secondLastOutputFrame", ByteArray(bytesPerFrame))
+
+        val frames = 240
+        val chunk = makeAudioChunkReflective(ByteArray(frames * 4) { 0x42 }, frames)
+
+        player.setSyncMuted(true)
+        player.setFreeRunning(true)
+        invokePlayChunkWithCorrection(player, chunk)
+
+        val record = fakeSink.writes.firstOrNull()
+            ?: error("expected one write to fake sink")
+        assertTrue(
+            "free-running has no sync to lose, so must not mute",
+            record.snapshotFirstBytes.all { it == 0x42.toByte() },
+        )
+    }
+
     /**
      * Build a SyncAudioPlayer wired to the shared `nowNs` clock and a
      * relaxed time-filter mock. Used by the watchdog tests.
@@ -1,5 +1,6 @@
 package com.sendspindroid.sendspin
 
+import com.sendspindroid.sendspin.audio.FakeAudioSink
 import io.mockk.every
 import io.mockk.mockk
 import io.mockk.verify
@@ -652,6 +653,18 @@ class SyncAudioPlayerTest {
         assertEquals(0, getChunkQueue().size)
     }
 
+    @Test
+    fun `setDucked lowers track gain and restores it exactly`() {
+        val sink = FakeAudioSink()
+        setField("audioSink", sink)
+
+        player.setDucked(true)
+        assertTrue("Ducked gain should be below full", sink.gain < 1f)
+
+        player.setDucked(false)
+        assertEquals(1f, sink.gain)
+    }
+
     @Test
     fun `REANCHORING to WAITING_FOR_START on new chunk`() {
         setField("playbackState", PlaybackState.REANCHORING)
@@ -0,0 +1,201 @@
+package com.sendspindroid.sendspin.audio
+
+import org.junit.Assert.assertArrayEquals
+import org.junit.Assert.assertEquals
+import org.junit.Assert.assertTrue
+import org.junit.Test
+import java.nio.ByteBuffer
+import java.nio.ByteOrder
+
+class ChannelMixerTest {
+
+    @Test
+    fun `centered balance leaves samples untouched`() {
+        val mixer = ChannelMixer(channels = 2, bitDepth = 16)
+        val pcm = stereo16(1000 to -1000, 32767 to -32768)
+        val original = pcm.copyOf()
+
+        mixer.process(pcm)
+
+        assertTrue(mixer.isPassThrough)
+        assertArrayEquals(original, pcm)
+    }
+
+    @Test
+    fun `balance right attenuates left only`() {
+        val mixer = ChannelMixer(channels = 2, bitDepth = 16)
+        mixer.balance = 0.5f
+        val pcm = stereo16(10000 to 10000, -20000 to -20000)
+
+        mixer.process(pcm)
+
+        assertEquals(listOf(5000 to 10000, -10000 to -20000), frames16(pcm))
+    }
+
+    @Test
+    fun `full left silences right`() {
+        val mixer = ChannelMixer(channels = 2, bitDepth = 16)
+        mixer.balance = -1f
+        val pcm = stereo16(12345 to 12345)
+
+        mixer.process(pcm)
+
+        assertEquals(listOf(12345 to 0), frames16(pcm))
+    }
+
+    @Test
+    fun `balance is clamped to range`() {
+        val mixer = ChannelMixer(channels = 2, bitDepth = 16)
+        mixer.balance = 3f
+        assertEquals(1f, mixer.balance)
+        mixer.balance = -3f
+        assertEquals(-1f, mixer.balance)
+    }
+
+    @Test
+    fun `NaN balance centers`() {
+        val mixer = ChannelMixer(channels = 2, bitDepth = 16)
+        mixer.balance = 0.5f
+        mixer.balance = Float.NaN
+        assertEquals(0f, mixer.balance)
+        assertTrue(mixer.isPassThrough)
+    }
+
+    @Test
+    fun `mono stream ignores balance`() {
+        val mixer = ChannelMixer(channels = 1, bitDepth = 16)
+        mixer.balance = 1f
+        val pcm = stereo16(1000 to 2000)
+        val original = pcm.copyOf()
+
+        mixer.process(pcm)
+
+        assertArrayEquals(original, pcm)
+    }
+
+    @Test
+    fun `mono output sums at -3dB onto both channels`() {
+        val mixer = ChannelMixer(channels = 2, bitDepth = 16)
+        mixer.monoOutput = true
+        val pcm = stereo16(10000 to 0, 10000 to -10000)
+
+        mixer.process(pcm)
+
+        assertEquals(listOf(7071 to 7071, 0 to 0), frames16(pcm))
+    }
+
+    @Test
+    fun `mono sum of full-scale channels clamps instead of wrapping`() {
+        val mixer = ChannelMixer(channels = 2, bitDepth = 16)
+        mixer.monoOutput = true
+        val pcm = stereo16(32767 to 32767, -32768 to -32768)
+
+        mixer.process(pcm)
+
+        assertEquals(listOf(32767 to 32767, -32768 to -32768), frames16(pcm))
+    }
+
+    @Test
+    fun `balance pans the mono downmix`() {
+        val mixer = ChannelMixer(channels = 2, bitDepth = 16)
+        mixer.monoOutput = true
+        mixer.balance = -1f
+        // Content only on the right: still audible on the left after downmix
+        val pcm = stereo16(0 to 10000)
+
+        mixer.process(pcm)
+
+        assertEquals(listOf(7071 to 0), frames16(pcm))
+    }
+
+    @Test
+    fun `24-bit packed samples keep sign`() {
+        val mixer = ChannelMixer(channels = 2, bitDepth = 24)
+        mixer.balance = 0.5f
+        // L = -4_000_000, R = 4_000_000, little-endian packed
+        val pcm = byteArrayOf(
+            0x00, 0xF7.toByte(), 0xC2.toByte(),
+            0x00, 0x09, 0x3D
+        )
+
+        mixer.process(pcm)
+
+        assertEquals(-2_000_000, read24(pcm, 0))
+        assertEquals(4_000_000, read24(pcm, 3))
+    }
+
+    @Test
+    fun `32-bit mono sum keeps full precision`() {
+        val mixer = ChannelMixer(channels = 2, bitDepth = 32)
+        mixer.monoOutput = true
+        // A Float sum would land on 174_594_272
+        val pcm = stereo32(123_456_789 to 123_456_789, Int.MAX_VALUE to Int.MAX_VALUE)
+
+        mixer.process(pcm)
+
+        assertEquals(
+            listOf(174_594_265 to 174_594_265, Int.MAX_VALUE to Int.MAX_VALUE),
+            frames32(pcm)
+        )
+    }
+
+    @Test
+    fun `centered balance leaves 32-bit samples untouched`() {
+        val mixer = ChannelMixer(channels = 2, bitDepth = 32)
+        mixer.balance = 0.5f
+        mixer.balance = 0f
+        val pcm = stereo32(123_456_789 to -123_456_789, Int.MAX_VALUE to Int.MIN_VALUE)
+        val original = pcm.copyOf()
+
+        mixer.process(pcm)
+
+        assertArrayEquals(original, pcm)
+    }
+
+    @Test
+    fun `32-bit balance keeps full precision`() {
+        val mixer = ChannelMixer(channels = 2, bitDepth = 32)
+        mixer.balance = 0.5f
+        // Not representable in Float: a Float gain would round both sides
+        val pcm = stereo32(123_456_789 to 123_456_789)
+
+        mixer.process(pcm)
+
+        assertEquals(listOf(61_728_394 to 123_456_789), frames32(pcm))
+    }
+
+    // --- helpers ---
+
+    private fun stereo16(vararg frames: Pair<Int, Int>): ByteArray {
+        val buf = ByteBuffer.allocate(frames.size * 4).order(ByteOrder.LITTLE_ENDIAN)
+        frames.forEach { (l, r) ->
+            buf.putShort(l.toShort())
+            buf.putShort(r.toShort())
+        }
+        return buf.array()
+    }
+
+    private fun frames16(pcm: ByteArray): List<Pair<Int, Int>> {
+        val buf = ByteBuffer.wrap(pcm).order(ByteOrder.LITTLE_ENDIAN)
+        return (0 until pcm.size / 4).map { buf.short.toInt() to buf.short.toInt() }
+    }
+
+    private fun stereo32(vararg frames: Pair<Int, Int>): ByteArray {
+        val buf = ByteBuffer.allocate(frames.size * 8).order(ByteOrder.LITTLE_ENDIAN)
+        frames.forEach { (l, r) ->
+            buf.putInt(l)
+            buf.putInt(r)
+        }
+        return buf.array()
+    }
+
+    private fun frames32(pcm: ByteArray): List<Pair<Int, Int>> {
+        val buf = ByteBuffer.wrap(pcm).order(ByteOrder.LITTLE_ENDIAN)
+        return (0 until pcm.size / 8).map { buf.int to buf.int }
+    }
+
+    private fun read24(pcm: ByteArray, offset: Int): Int =
+        (pcm[offset].toInt() and 0xFF) or
+            ((pcm[offset + 1].toInt() and 0xFF) shl 8) or
+            (pcm[offset + 2].toInt() shl 16)
+}
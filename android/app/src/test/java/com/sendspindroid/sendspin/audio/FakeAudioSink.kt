@@ -70,11 +70,19 @@ class FakeAudioSink(
 
     @Volatile var scriptedPlaybackHeadPosition: Int = 0
 
+    /** Last gain passed to setVolume(); AudioTrack starts at full gain. */
+    @Volatile var gain: Float = 1f
+        private set
+
     override fun play() { playCallCount.incrementAndGet() }
     override fun pause() { pauseCallCount.incrementAndGet() }
     override fun stop() { stopCallCount.incrementAndGet() }
     override fun flush() { flushCallCount.incrementAndGet() }
     override fun release() { releaseCallCount.incrementAndGet() }
+    override fun setVolume(gain: Float): Int {
+        this.gain = gain
+        return 0
+    }
 
     override fun write(buffer: ByteArray, offset: Int, size: Int): Int {
         val snapshotEnd = minOf(offset + 16, offset + size)
@@ -1,5 +1,6 @@
 package com.sendspindroid.sendspin.protocol
 
+import com.sendspindroid.model.PlaybackStateType
 import com.sendspindroid.sendspin.SendspinTimeFilter
 import com.sendspindroid.sendspin.protocol.message.MessageBuilder
 import kotlinx.coroutines.CoroutineScope
@@ -59,6 +60,21 @@ class SendSpinProtocolHandlerTest {
         assertEquals(100, handler.exposedVolume())
     }
 
+    @Test
+    fun `setVolume ignores NaN and keeps the current volume`() {
+        handler.setVolume(0.3)
+        handler.setVolume(Double.NaN)
+        assertEquals(30, handler.exposedVolume())
+    }
+
+    @Test
+    fun `setInitialVolume clamps out-of-range values`() {
+        handler.setInitialVolume(150)
+        assertEquals(100, handler.exposedVolume())
+        handler.setInitialVolume(-3)
+        assertEquals(0, handler.exposedVolume())
+    }
+
     // ========== Metadata Dispatch Tests ==========
 
     @Test
@@ -383,8 +399,193 @@ class SendSpinProtocolHandlerTest {
         assertEquals(44100, handler.streamStarts[1].sampleRate)
     }
 
+    // ========== Pending Command Queue Tests ==========
+
+    @Test
+    fun `commands issued while connecting are sent after server hello`() {
+        handler.resetHandshakeForTest()
+        handler.connecting = true
+        handler.queueCommandsWhileConnecting = true
+        handler.sendCommand("play")
+        handler.sendCommand("next")
+        assertTrue("Nothing sent before handshake", handler.sentMessages.isEmpty())
+
+        handler.handleTextMessageForTest(serverHelloJson)
+
+        val commands = handler.sentMessages.filter { it.contains("client/command") }
+        assertEquals(2, commands.size)
+        assertTrue(commands[0].contains("\"play\""))
+        assertTrue(commands[1].contains("\"next\""))
+    }
+
+    @Test
+    fun `queued volume and transport commands keep only the latest`() {
+        handler.resetHandshakeForTest()
+        handler.connecting = true
+        handler.queueCommandsWhileConnecting = true
+        handler.sendCommand("volume", volume = 20)
+        handler.sendCommand("play")
+        handler.sendCommand("volume", volume = 70)
+        handler.sendCommand("pause")
+
+        handler.handleTextMessageForTest(serverHelloJson)
+
+        val commands = handler.sentMessages.filter { it.contains("client/command") }
+        assertEquals(2, commands.size)
+        assertTrue(commands[0].contains("\"volume\":70"))
+        assertTrue(commands[1].contains("\"pause\""))
+    }
+
+    @Test
+    fun `command queue is bounded`() {
+        handler.resetHandshakeForTest()
+        handler.connecting = true
+        handler.queueCommandsWhileConnecting = true
+        repeat(20) { handler.sendCommand("next") }
+
+        handler.handleTextMessageForTest(serverHelloJson)
+
+        assertEquals(8, handler.sentMessages.count { it.contains("client/command") })
+    }
+
+    @Test
+    fun `commands are not queued by default`() {
+        handler.resetHandshakeForTest()
+        handler.connecting = true
+        handler.sendCommand("play")
+
+        assertEquals(1, handler.sentMessages.size)
+        handler.sentMessages.clear()
+        handler.handleTextMessageForTest(serverHelloJson)
+        assertTrue(handler.sentMessages.none { it.contains("client/command") })
+    }
+
+    // ========== Playback State Tests ==========
+
+    @Test
+    fun `server state is normalized and deduplicated across casing`() {
+        handler.handleTextMessageForTest("""{"type":"server/state","payload":{"state":"Playing"}}""")
+        handler.handleTextMessageForTest("""{"type":"server/state","payload":{"state":"PLAYING"}}""")
+        handler.handleTextMessageForTest("""{"type":"server/state","payload":{"state":"weird"}}""")
+
+        assertEquals(
+            listOf(PlaybackStateType.PLAYING, PlaybackStateType.UNKNOWN),
+            handler.playbackStateChanges
+        )
+    }
+
+    // ========== Artwork Size Cap Tests ==========
+
+    @Test
+    fun `artwork within the cap is delivered`() {
+        handler.maxArtworkBytes = 16
+        handler.handleBinaryMessageForTest(artworkFrame(ByteArray(16) { 1 }))
+
+        assertEquals(1, handler.artwork.size)
+        assertEquals(16, handler.artwork[0].size)
+    }
+
+    @Test
+    fun `oversized artwork is dropped and the channel cleared`() {
+        handler.maxArtworkBytes = 16
+        handler.handleBinaryMessageForTest(artworkFrame(ByteArray(17) { 1 }))
+
+        assertEquals(1, handler.artwork.size)
+        assertEquals("Oversized artwork should clear, not deliver", 0, handler.artwork[0].size)
+    }
+
+    @Test
+    fun `non-positive artwork cap falls back to the default`() {
+        handler.maxArtworkBytes = 0
+        assertEquals(SendSpinProtocol.Artwork.MAX_BYTES, handler.maxArtworkBytes)
+
+        handler.maxArtworkBytes = -1
+        assertEquals(SendSpinProtocol.Artwork.MAX_BYTES, handler.maxArtworkBytes)
+
+        handler.maxArtworkBytes = 1
+        assertEquals(1, handler.maxArtworkBytes)
+    }
+
+    // ========== Mislabeled Binary Sniffing Tests ==========
+
+    private val pngHeader = byteArrayOf(0x89.toByte(), 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A)
+    private val jpegHeader = byteArrayOf(0xFF.toByte(), 0xD8.toByte(), 0xFF.toByte(), 0xE0.toByte())
+
+    @Test
+    fun `image in an audio frame goes to artwork when sniffing is on`() {
+        handler.sniffMislabeledBinary = true
+        startPcmStream()
+
+        handler.handleBinaryMessageForTest(audioFrame(pngHeader + ByteArray(32)))
+        handler.handleBinaryMessageForTest(audioFrame(jpegHeader + ByteArray(32)))
+
+        assertEquals(0, handler.audioChunks.size)
+        assertEquals(2, handler.artwork.size)
+    }
+
+    @Test
+    fun `image in an audio frame is left alone when sniffing is off`() {
+        startPcmStream()
+
+        handler.handleBinaryMessageForTest(audioFrame(pngHeader + ByteArray(32)))
+
+        assertEquals(1, handler.audioChunks.size)
+        assertEquals(0, handler.artwork.size)
+    }
+
+    @Test
+    fun `ordinary audio passes through with sniffing on`() {
+        handler.sniffMislabeledBinary = true
+        startPcmStream()
+
+        // 0xFF 0xD8 0xFF followed by a non-marker byte is plausible PCM
+        handler.handleBinaryMessageForTest(audioFrame(byteArrayOf(-1, -40, -1, 0x12) + ByteArray(32)))
+        handler.handleBinaryMessageForTest(audioFrame(ByteArray(64) { it.toByte() }))
+
+        assertEquals(2, handler.audioChunks.size)
+        assertEquals(0, handler.artwork.size)
+    }
+
+    @Test
+    fun `JSON in a binary frame is handled as text when sniffing is on`() {
+        handler.sniffMislabeledBinary = true
+        startPcmStream()
+
+        val json = buildServerStateJson(title = "Song", artist = "Artist", album = "Album")
+        handler.handleBinaryMessageForTest(json.encodeToByteArray())
+
+        assertEquals(1, handler.metadataUpdates.size)
+        assertEquals("Song", handler.metadataUpdates[0].title)
+        assertEquals(0, handler.audioChunks.size)
+    }
+
+    @Test
+    fun `JSON in a binary frame is ignored when sniffing is off`() {
+        startPcmStream()
+
+        val json = buildServerStateJson(title = "Song", artist = "Artist", album = "Album")
+        handler.handleBinaryMessageForTest(json.encodeToByteArray())
+
+        assertEquals(0, handler.metadataUpdates.size)
+        assertEquals(0, handler.audioChunks.size)
+    }
+
     // ========== Helpers ==========
 
+    /** Artwork channel 0 frame: type byte, 8-byte timestamp, image bytes. */
+    private fun artworkFrame(image: ByteArray): ByteArray =
+        byteArrayOf(SendSpinProtocol.BinaryType.ARTWORK_BASE.toByte()) + ByteArray(8) + image
+
+    private fun startPcmStream() {
+        handler.handleTextMessageForTest(
+            buildStreamStartJson(codec = "pcm", sampleRate = 48000, channels = 2, bitDepth = 16)
+        )
+    }
+
+    /** Audio frame: type byte, 8-byte timestamp, payload. */
+    private fun audioFrame(payload: ByteArray): ByteArray =
+        byteArrayOf(SendSpinProtocol.BinaryType.AUDIO.toByte()) + ByteArray(8) + payload
+
     private fun buildServerStateJson(
         title: String,
         artist: String,
@@ -415,6 +616,9 @@ class SendSpinProtocolHandlerTest {
         """.trimIndent()
     }
 
+    private val serverHelloJson =
+        """{"type":"server/hello","payload":{"name":"Test","server_id":"s1","active_roles":["player@v1"]}}"""
+
     private fun buildStreamStartJson(
         codec: String,
         sampleRate: Int,
@@ -448,15 +652,22 @@ class TestProtocolHandler : SendSpinProtocolHandler("TestHandler") {
     val sentMessages = mutableListOf<String>()
     val metadataUpdates = mutableListOf<TrackMetadata>()
     val controllerStateUpdates = mutableListOf<ControllerState>()
-    val playbackStateChanges = mutableListOf<String>()
+    val playbackStateChanges = mutableListOf<PlaybackStateType>()
     val groupUpdates = mutableListOf<GroupInfo>()
     val streamStarts = mutableListOf<StreamConfig>()
     val muteEvents = mutableListOf<Boolean>()
+    val artwork = mutableListOf<ByteArray>()
+    val audioChunks = mutableListOf<ByteArray>()
+    var connecting = false
 
     fun setHandshakeCompleteForTest() {
         handshakeComplete = true
     }
 
+    fun resetHandshakeForTest() {
+        handshakeComplete = false
+    }
+
     fun exposedVolume(): Int = currentVolume
     fun exposedSyncState(): String = currentSyncState
     fun exposedTimeFilter(): SendspinTimeFilter = timeFilter
@@ -468,10 +679,16 @@ class TestProtocolHandler : SendSpinProtocolHandler("TestHandler") {
         handleTextMessage(text)
     }
 
+    fun handleBinaryMessageForTest(bytes: ByteArray) {
+        handleBinaryMessage(bytes)
+    }
+
     override fun sendTextMessage(text: String) {
         sentMessages.add(text)
     }
 
+    override fun isConnecting(): Boolean = connecting
+
     override fun getCoroutineScope(): CoroutineScope = testScope
 
     override fun getTimeFilter(): SendspinTimeFilter = timeFilter
@@ -498,7 +715,7 @@ class TestProtocolHandler : SendSpinProtocolHandler("TestHandler") {
         controllerStateUpdates.add(state)
     }
 
-    override fun onPlaybackStateChanged(state: String) {
+    override fun onPlaybackStateChanged(state: PlaybackStateType) {
         playbackStateChanges.add(state)
     }
 
@@ -516,11 +733,17 @@ class TestProtocolHandler : SendSpinProtocolHandler("TestHandler") {
 
     override fun onStreamClear() {}
 
+    override fun onFlushBuffers() {}
+
     override fun onStreamEnd() {}
 
-    override fun onAudioChunk(timestampMicros: Long, audioData: ByteArray) {}
+    override fun onAudioChunk(timestampMicros: Long, audioData: ByteArray) {
+        audioChunks.add(audioData)
+    }
 
-    override fun onArtwork(channel: Int, payload: ByteArray) {}
+    override fun onArtwork(channel: Int, payload: ByteArray) {
+        artwork.add(payload)
+    }
 
     override fun onSyncOffsetApplied(offsetMs: Double, source: String) {}
 
@@ -109,14 +109,21 @@ class PlaybackStateTest {
     }
 
     @Test
-    fun playbackStateType_fromString_unknown_returnsIdle() {
-        assertEquals(PlaybackStateType.IDLE, PlaybackStateType.fromString("garbage"))
+    fun playbackStateType_fromString_unknown_returnsUnknown() {
+        assertEquals(PlaybackStateType.UNKNOWN, PlaybackStateType.fromString("garbage"))
+        assertEquals(PlaybackStateType.UNKNOWN, PlaybackStateType.fromString(""))
+    }
+
+    @Test
+    fun playbackStateType_fromString_idle() {
+        assertEquals(PlaybackStateType.IDLE, PlaybackStateType.fromString("idle"))
     }
 
     @Test
     fun playbackStateType_fromString_caseInsensitive() {
         assertEquals(PlaybackStateType.PLAYING, PlaybackStateType.fromString("PLAYING"))
         assertEquals(PlaybackStateType.PAUSED, PlaybackStateType.fromString("Paused"))
+        assertEquals(PlaybackStateType.STOPPED, PlaybackStateType.fromString(" Stopped\n"))
     }
 
     // --- withMetadata ---
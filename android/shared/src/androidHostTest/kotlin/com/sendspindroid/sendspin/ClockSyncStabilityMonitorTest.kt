@@ -0,0 +1,48 @@
+package com.sendspindroid.sendspin
+
+import org.junit.Assert.*
+import org.junit.Test
+
+class ClockSyncStabilityMonitorTest {
+
+    private val monitor = ClockSyncStabilityMonitor(unstableAfterMs = 1_000)
+
+    @Test
+    fun `reports once when sync never converges`() {
+        assertFalse(monitor.update(0, converged = false))
+        assertFalse(monitor.update(999, converged = false))
+        assertTrue(monitor.update(1_000, converged = false))
+        assertFalse("Only reported once per window", monitor.update(5_000, converged = false))
+    }
+
+    @Test
+    fun `converging within the window never reports`() {
+        monitor.update(0, converged = false)
+        monitor.update(500, converged = true)
+
+        assertFalse(monitor.update(1_200, converged = false))
+        assertFalse(monitor.update(1_400, converged = true))
+    }
+
+    @Test
+    fun `losing convergence later starts a fresh window`() {
+        monitor.update(0, converged = false)
+        assertTrue(monitor.update(1_000, converged = false))
+        monitor.update(2_000, converged = true)
+
+        assertFalse(monitor.update(3_000, converged = false))
+        assertFalse(monitor.update(3_999, converged = false))
+        assertTrue(monitor.update(4_000, converged = false))
+    }
+
+    @Test
+    fun `reset rearms the monitor`() {
+        monitor.update(0, converged = false)
+        assertTrue(monitor.update(1_000, converged = false))
+
+        monitor.reset()
+
+        assertFalse(monitor.update(10_000, converged = false))
+        assertTrue(monitor.update(11_000, converged = false))
+    }
+}
@@ -0,0 +1,50 @@
+package com.sendspindroid.sendspin
+
+import org.junit.Assert.*
+import org.junit.Test
+
+class ServerOverflowDetectorTest {
+
+    // 10 s of buffer draining at 1000 B/s
+    private val detector = ServerOverflowDetector(capacityBytes = 10_000, drainBytesPerSec = 1_000)
+
+    @Test
+    fun `initial burst up to capacity is allowed`() {
+        assertNull(detector.update(0, 10_000))
+        assertNull(detector.update(1_000, 1_000))
+        assertNull(detector.update(2_000, 1_000))
+    }
+
+    @Test
+    fun `sustained over-sending reports the observed rate once`() {
+        // Server sends 3000 B/s against a 1000 B/s drain
+        var report: Long? = null
+        var t = 0L
+        while (report == null && t <= 10_000) {
+            report = detector.update(t, 3_000)
+            t += 1_000
+        }
+
+        assertNotNull("Over-sending should be reported within 10 s", report)
+        assertTrue("Observed rate should exceed the drain rate", report!! > 1_000)
+        assertNull("Only reported once per stream", detector.update(t, 3_000))
+    }
+
+    @Test
+    fun `real-time pacing after the burst never reports`() {
+        assertNull(detector.update(0, 10_000))
+        for (t in 1..60) {
+            assertNull(detector.update(t * 1_000L, 1_000))
+        }
+    }
+
+    @Test
+    fun `reset rearms the detector`() {
+        assertNotNull(detector.update(0, 20_000))
+
+        detector.reset()
+
+        assertNull(detector.update(5_000, 10_000))
+        assertNotNull(detector.update(5_000, 1))
+    }
+}
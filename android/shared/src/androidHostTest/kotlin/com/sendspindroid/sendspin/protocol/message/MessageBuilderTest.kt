@@ -305,6 +305,50 @@ class MessageBuilderTest {
         assertTrue(formats.all { it.bitDepth == 16 })
     }
 
+    // --- filterFormatsForOutput ---
+
+    private val flacAndPcm24 = MessageBuilder.buildSupportedFormats(
+        preferredCodec = "flac",
+        isCodecSupported = { it in listOf("flac", "pcm") },
+        supportedBitDepths = listOf(16, 24)
+    )
+
+    @Test
+    fun filterFormatsForOutput_bitDepthOnlyFiltersPcm() {
+        val formats = MessageBuilder.filterFormatsForOutput(
+            flacAndPcm24, sampleRates = emptyList(), bitDepths = listOf(16), maxChannels = 0
+        )
+        // flac stereo/mono + pcm 16-bit stereo/mono; pcm 24-bit dropped
+        assertEquals(4, formats.size)
+        assertEquals("flac", formats[0].codec)
+        assertTrue(formats.all { it.bitDepth == 16 })
+    }
+
+    @Test
+    fun filterFormatsForOutput_monoOutputDropsStereo() {
+        val formats = MessageBuilder.filterFormatsForOutput(
+            flacAndPcm24, sampleRates = emptyList(), bitDepths = emptyList(), maxChannels = 1
+        )
+        assertEquals(3, formats.size)
+        assertTrue(formats.all { it.channels == 1 })
+    }
+
+    @Test
+    fun filterFormatsForOutput_unreportedDimensionsKeepEverything() {
+        val formats = MessageBuilder.filterFormatsForOutput(
+            flacAndPcm24, sampleRates = emptyList(), bitDepths = emptyList(), maxChannels = 0
+        )
+        assertEquals(flacAndPcm24, formats)
+    }
+
+    @Test
+    fun filterFormatsForOutput_nothingCompatibleFallsBackToInput() {
+        val formats = MessageBuilder.filterFormatsForOutput(
+            flacAndPcm24, sampleRates = listOf(44100), bitDepths = emptyList(), maxChannels = 0
+        )
+        assertEquals(flacAndPcm24, formats)
+    }
+
     // --- calculateBufferCapacity ---
 
     @Test
@@ -1,5 +1,6 @@
 package com.sendspindroid.sendspin.protocol.message
 
+import com.sendspindroid.model.PlaybackStateType
 import com.sendspindroid.sendspin.protocol.ServerCommandResult
 import com.sendspindroid.shared.log.Log
 import com.sendspindroid.shared.platform.Platform
@@ -198,7 +199,7 @@ class MessageParserTest {
         assertEquals("Album Artist", metadata.albumArtist)
         assertEquals(45000L, metadata.progress.trackProgress)
         assertEquals(180000L, metadata.progress.trackDuration)
-        assertEquals("playing", state)
+        assertEquals(PlaybackStateType.PLAYING, state)
     }
 
     @Test
@@ -234,7 +235,7 @@ class MessageParserTest {
         }
         val (metadata, state) = MessageParser.parseServerState(payload)
         assertNull(metadata)
-        assertEquals("paused", state)
+        assertEquals(PlaybackStateType.PAUSED, state)
     }
 
     @Test
@@ -279,7 +280,7 @@ class MessageParserTest {
         }
         val (metadata, state) = MessageParser.parseServerState(payload)
         assertNull(metadata)
-        assertEquals("stopped", state)
+        assertEquals(PlaybackStateType.STOPPED, state)
     }
 
     @Test
@@ -478,7 +479,25 @@ class MessageParserTest {
         assertNotNull(result)
         assertEquals("group-1", result!!.groupId)
         assertEquals("Living Room", result.groupName)
-        assertEquals("playing", result.playbackState)
+        assertEquals(PlaybackStateType.PLAYING, result.playbackState)
+    }
+
+    @Test
+    fun parseGroupUpdate_playbackState_normalized() {
+        fun stateOf(value: String) = MessageParser.parseGroupUpdate(
+            buildJsonObject { put("playback_state", value) }
+        )!!.playbackState
+
+        assertEquals(PlaybackStateType.PLAYING, stateOf("PLAYING"))
+        assertEquals(PlaybackStateType.PAUSED, stateOf(" Paused "))
+        assertEquals(PlaybackStateType.IDLE, stateOf("idle"))
+        assertEquals(PlaybackStateType.UNKNOWN, stateOf("rewinding"))
+    }
+
+    @Test
+    fun parseGroupUpdate_playbackStateAbsent_isNull() {
+        val payload = buildJsonObject { put("group_id", "group-1") }
+        assertNull(MessageParser.parseGroupUpdate(payload)!!.playbackState)
     }
 
     @Test
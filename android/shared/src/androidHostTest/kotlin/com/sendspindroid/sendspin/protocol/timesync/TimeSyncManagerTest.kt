@@ -257,6 +257,76 @@ class TimeSyncManagerTest {
         assertFalse("onServerTime should return false after stop", result)
     }
 
+    // --- Converged interval ---
+
+    @Test
+    fun convergedInterval_defaultsToThreeSeconds() {
+        assertEquals(TimeSyncManager.DEFAULT_CONVERGED_INTERVAL_MS, manager.convergedIntervalMs)
+    }
+
+    @Test
+    fun convergedInterval_isClampedToRange() {
+        manager.convergedIntervalMs = 100
+        assertEquals(TimeSyncManager.MIN_CONVERGED_INTERVAL_MS, manager.convergedIntervalMs)
+
+        manager.convergedIntervalMs = 60_000
+        assertEquals(TimeSyncManager.MAX_CONVERGED_INTERVAL_MS, manager.convergedIntervalMs)
+
+        manager.convergedIntervalMs = 1_000
+        assertEquals(1_000L, manager.convergedIntervalMs)
+    }
+
+    @Test
+    fun convergedInterval_nonPositiveFallsBackToDefault() {
+        manager.convergedIntervalMs = 1_000
+        manager.convergedIntervalMs = 0
+        assertEquals(TimeSyncManager.DEFAULT_CONVERGED_INTERVAL_MS, manager.convergedIntervalMs)
+
+        manager.convergedIntervalMs = 1_000
+        manager.convergedIntervalMs = -500
+        assertEquals(TimeSyncManager.DEFAULT_CONVERGED_INTERVAL_MS, manager.convergedIntervalMs)
+    }
+
+    @Test
+    fun convergedInterval_survivesStop() = runTest {
+        manager.convergedIntervalMs = 1_000
+        manager.start(this)
+        manager.stop()
+
+        assertEquals(1_000L, manager.convergedIntervalMs)
+    }
+
+    // --- Keepalive-only (clock sync disabled) ---
+
+    @Test
+    fun keepaliveOnly_sendsSinglePacketsAtMaxInterval() = runTest {
+        manager.keepaliveOnly = true
+        manager.start(this)
+
+        advanceTimeBy(10)
+        assertEquals("No burst, just one keepalive", 1, sendCount)
+
+        advanceTimeBy(TimeSyncManager.MAX_CONVERGED_INTERVAL_MS)
+        assertEquals(2, sendCount)
+
+        manager.stop()
+    }
+
+    @Test
+    fun keepaliveOnly_ignoresReplies() = runTest {
+        manager.keepaliveOnly = true
+        manager.start(this)
+        advanceTimeBy(10)
+
+        val buffered = manager.onServerTime(
+            TimeMeasurement(offset = 10_000L, rtt = 5_000L, clientReceived = 1_000_000L)
+        )
+
+        assertFalse(buffered)
+        assertEquals(0, timeFilter.measurementCountValue)
+        manager.stop()
+    }
+
     // --- H-03: stop() resets all mutable state inside synchronized block ---
 
     @Test
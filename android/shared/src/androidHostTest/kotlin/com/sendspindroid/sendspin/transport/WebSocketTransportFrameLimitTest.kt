@@ -0,0 +1,151 @@
+package com.sendspindroid.sendspin.transport
+
+import com.sendspindroid.shared.log.Log
+import io.mockk.every
+import io.mockk.mockkObject
+import io.mockk.unmockkAll
+import org.junit.After
+import org.junit.Assert.assertEquals
+import org.junit.Assert.assertTrue
+import org.junit.Before
+import org.junit.Test
+import java.net.InetAddress
+import java.net.ServerSocket
+import java.security.MessageDigest
+import java.util.Base64
+import java.util.concurrent.CountDownLatch
+import java.util.concurrent.TimeUnit
+import kotlin.concurrent.thread
+
+/**
+ * Tests for the post-receive frame size check in [BaseWebSocketTransport].
+ *
+ * A minimal WebSocket server on loopback completes the upgrade and pushes a
+ * single binary frame, so the real OkHttp engine and receive loop are exercised.
+ */
+class WebSocketTransportFrameLimitTest {
+
+    private var server: ServerSocket? = null
+    private var transport: WebSocketTransport? = null
+
+    @Before
+    fun setUp() {
+        mockkObject(Log)
+        every { Log.v(any(), any()) } returns 0
+        every { Log.d(any(), any()) } returns 0
+        every { Log.i(any(), any()) } returns 0
+        every { Log.w(any(), any<String>()) } returns 0
+        every { Log.e(any(), any()) } returns 0
+    }
+
+    @After
+    fun tearDown() {
+        transport?.destroy()
+        server?.close()
+        unmockkAll()
+    }
+
+    @Test
+    fun `oversized frame is dropped after receipt and closes with 1009`() {
+        val listener = RecordingListener()
+        connect(limit = 1024, payloadSize = 4096, listener = listener)
+
+        assertTrue("onClosed should fire", listener.closed.await(5, TimeUnit.SECONDS))
+        assertEquals(BaseWebSocketTransport.CLOSE_CODE_TOO_BIG, listener.closeCode)
+        assertEquals(0, listener.binaryMessages)
+        assertEquals(TransportState.Closed, transport?.state)
+    }
+
+    @Test
+    fun `frame at the limit is delivered`() {
+        val listener = RecordingListener()
+        connect(limit = 1024, payloadSize = 1024, listener = listener)
+
+        assertTrue("Frame should be delivered", listener.message.await(5, TimeUnit.SECONDS))
+        assertEquals(1, listener.binaryMessages)
+        assertEquals(TransportState.Connected, transport?.state)
+    }
+
+    @Test
+    fun `non-positive limit falls back to the default`() {
+        val listener = RecordingListener()
+        connect(limit = 0, payloadSize = 4096, listener = listener)
+
+        assertTrue("Frame should be delivered", listener.message.await(5, TimeUnit.SECONDS))
+        assertEquals(1, listener.binaryMessages)
+    }
+
+    // --- helpers ---
+
+    private fun connect(limit: Int, payloadSize: Int, listener: RecordingListener) {
+        val socket = ServerSocket(0, 1, InetAddress.getLoopbackAddress())
+        server = socket
+        serveOneFrame(socket, payloadSize)
+
+        val t = WebSocketTransport(
+            address = "127.0.0.1:${socket.localPort}",
+            path = "/sendspin",
+            maxFrameBytes = limit
+        )
+        transport = t
+        t.setListener(listener)
+        t.connect()
+    }
+
+    /** Accept one client, complete the upgrade, send an unmasked binary frame, then idle. */
+    private fun serveOneFrame(socket: ServerSocket, payloadSize: Int) {
+        thread(isDaemon = true) {
+            runCatching {
+                socket.accept().use { client ->
+                    client.soTimeout = 5000
+                    val input = client.getInputStream()
+                    val headers = StringBuilder()
+                    while (!headers.endsWith("\r\n\r\n")) {
+                        val b = input.read()
+                        if (b < 0) return@use
+                        headers.append(b.toChar())
+                    }
+                    val key = headers.lineSequence()
+                        .first { it.startsWith("Sec-WebSocket-Key:", ignoreCase = true) }
+                        .substringAfter(':').trim()
+                    val accept = Base64.getEncoder().encodeToString(
+                        MessageDigest.getInstance("SHA-1")
+                            .digest((key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11").toByteArray())
+                    )
+                    val out = client.getOutputStream()
+                    out.write(
+                        ("HTTP/1.1 101 Switching Protocols\r\n" +
+                            "Upgrade: websocket\r\nConnection: Upgrade\r\n" +
+                            "Sec-WebSocket-Accept: $accept\r\n\r\n").toByteArray()
+                    )
+                    // FIN + binary opcode, 16-bit extended payload length
+                    out.write(byteArrayOf(0x82.toByte(), 126, (payloadSize shr 8).toByte(), payloadSize.toByte()))
+                    out.write(ByteArray(payloadSize))
+                    out.flush()
+                    // Hold the connection open until the client goes away
+                    while (input.read() >= 0) { }
+                }
+            }
+        }
+    }
+
+    private class RecordingListener : SendSpinTransport.Listener {
+        val closed = CountDownLatch(1)
+        val message = CountDownLatch(1)
+        @Volatile var closeCode = -1
+        @Volatile var binaryMessages = 0
+
+        override fun onConnected() {}
+        override fun onMessage(text: String) {}
+        override fun onMessage(bytes: ByteArray) {
+            binaryMessages++
+            message.countDown()
+        }
+        override fun onClosing(code: Int, reason: String) {}
+        override fun onClosed(code: Int, reason: String) {
+            closeCode = code
+            closed.countDown()
+        }
+        override fun onFailure(error: Throwable, isRecoverable: Boolean) {}
+    }
+}
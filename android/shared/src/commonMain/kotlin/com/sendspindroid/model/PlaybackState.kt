@@ -102,20 +102,29 @@ data class PlaybackState(
     )
 }
 
+/**
+ * Normalized playback state. Servers differ in casing (and occasionally send
+ * values we don't know), so the wire string is mapped once at the protocol
+ * boundary via [fromString] and everything downstream compares enum values.
+ */
 enum class PlaybackStateType {
     IDLE,
     PLAYING,
     PAUSED,
     BUFFERING,
-    STOPPED;
+    STOPPED,
+
+    /** A value we don't recognize. Callers keep their current state. */
+    UNKNOWN;
 
     companion object {
-        fun fromString(value: String): PlaybackStateType = when (value.lowercase()) {
+        fun fromString(value: String): PlaybackStateType = when (value.trim().lowercase()) {
             "playing" -> PLAYING
             "paused" -> PAUSED
             "buffering" -> BUFFERING
             "stopped" -> STOPPED
-            else -> IDLE
+            "idle" -> IDLE
+            else -> UNKNOWN
         }
     }
 }
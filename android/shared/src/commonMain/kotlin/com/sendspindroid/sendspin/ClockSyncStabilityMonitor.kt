@@ -0,0 +1,57 @@
+package com.sendspindroid.sendspin
+
+/**
+ * Detects clock sync that never settles (high jitter, asymmetric latency),
+ * so the app can tell the user the network is too jittery for multi-room
+ * playback instead of leaving sync silently broken.
+ *
+ * Detection criteria: once measurements are flowing, the time filter has
+ * [unstableAfterMs] to reach [SendspinTimeFilter.isConverged] (estimated
+ * offset error below 10 ms). If it is still unconverged when that window
+ * elapses, [update] reports it once. Converging re-arms the monitor, so a
+ * link that later loses convergence and does not regain it within another
+ * full window reports again.
+ *
+ * A healthy LAN converges within a few seconds, and the burst schedule
+ * speeds up on high jitter, so 30 s is far past the point where waiting
+ * longer would help.
+ *
+ * Pure and deterministic: the caller passes a monotonic `nowMs` into every
+ * [update]. Not thread-safe; callers serialize access.
+ */
+class ClockSyncStabilityMonitor(
+    private val unstableAfterMs: Long = DEFAULT_UNSTABLE_AFTER_MS
+) {
+    companion object {
+        const val DEFAULT_UNSTABLE_AFTER_MS = 30_000L
+    }
+
+    private var windowStartMs = -1L
+    private var reported = false
+
+    /**
+     * Feed the filter state after a measurement has been applied.
+     *
+     * @return `true` exactly once per unconverged window that outlasts
+     *   [unstableAfterMs].
+     */
+    fun update(nowMs: Long, converged: Boolean): Boolean {
+        if (converged) {
+            reset()
+            return false
+        }
+        if (windowStartMs < 0) {
+            windowStartMs = nowMs
+            return false
+        }
+        if (reported || nowMs - windowStartMs < unstableAfterMs) return false
+        reported = true
+        return true
+    }
+
+    /** Start over, e.g. for a new connection. */
+    fun reset() {
+        windowStartMs = -1L
+        reported = false
+    }
+}
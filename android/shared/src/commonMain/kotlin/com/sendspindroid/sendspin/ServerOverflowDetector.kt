@@ -0,0 +1,56 @@
+package com.sendspindroid.sendspin
+
+/**
+ * Detects a server that keeps sending audio faster than the buffer capacity
+ * we advertised in client/hello allows, so drops caused by a server ignoring
+ * flow control can be told apart from drops caused by a slow device.
+ *
+ * Detection criteria: a well-behaved server may burst up to
+ * [capacityBytes] ahead at stream start and after that can only send as
+ * fast as audio is consumed, which is at most [drainBytesPerSec] (the
+ * highest bitrate we advertised). So from the start of a stream, the total
+ * received must stay within `capacityBytes + drainBytesPerSec * elapsed`.
+ * Exceeding that bound means the server has pushed more than a full buffer
+ * beyond what we could have played, which can only happen through sustained
+ * over-sending, never through a single late burst. [update] reports that
+ * once per stream.
+ *
+ * The observed rate is the mean ingress rate since the stream started.
+ *
+ * Pure and deterministic: the caller passes a monotonic `nowMs` into every
+ * [update]. Not thread-safe; callers serialize access.
+ */
+class ServerOverflowDetector(
+    private val capacityBytes: Long,
+    private val drainBytesPerSec: Long
+) {
+    private var startMs = -1L
+    private var receivedBytes = 0L
+    private var reported = false
+
+    /**
+     * Record [bytes] of audio payload received at [nowMs].
+     *
+     * @return the observed rate in bytes/sec exactly once per stream when the
+     *   server has exceeded the advertised capacity, otherwise null.
+     */
+    fun update(nowMs: Long, bytes: Int): Long? {
+        if (startMs < 0) startMs = nowMs
+        receivedBytes += bytes
+        if (reported) return null
+
+        val elapsedMs = nowMs - startMs
+        val allowedBytes = capacityBytes + drainBytesPerSec * elapsedMs / 1000
+        if (receivedBytes <= allowedBytes) return null
+
+        reported = true
+        return if (elapsedMs > 0) receivedBytes * 1000 / elapsedMs else receivedBytes
+    }
+
+    /** Start over, e.g. for a new stream. */
+    fun reset() {
+        startMs = -1L
+        receivedBytes = 0L
+        reported = false
+    }
+}
@@ -1,5 +1,7 @@
 package com.sendspindroid.sendspin.protocol
 
+import com.sendspindroid.model.PlaybackStateType
+
 /**
  * SendSpin Protocol constants and data classes.
  *
@@ -39,6 +41,7 @@ object SendSpinProtocol {
      */
     object Artwork {
         const val REQUEST_SIZE = 500  // Requested artwork width/height in pixels
+        const val MAX_BYTES = 4 * 1024 * 1024  // Default cap on a single artwork image
     }
 
     /**
@@ -266,17 +269,20 @@ data class ControllerState(
  */
 data class ServerStateResult(
     val metadata: TrackMetadata?,
-    val playbackState: String?,
+    val playbackState: PlaybackStateType?,
     val controller: ControllerState?
 )
 
 /**
  * Group information from group/update messages.
+ *
+ * @param playbackState Normalized `playback_state`; null when the update
+ *   didn't carry one (delta semantics - keep the previous state).
  */
 data class GroupInfo(
     val groupId: String,
     val groupName: String,
-    val playbackState: String
+    val playbackState: PlaybackStateType?
 )
 
 /**
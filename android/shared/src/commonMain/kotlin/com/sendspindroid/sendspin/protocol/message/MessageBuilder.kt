@@ -16,6 +16,17 @@ object MessageBuilder {
         val bitDepth: Int
     )
 
+    /**
+     * Roles advertised in client/hello. Artwork is left out in low-memory
+     * mode so the server doesn't push images we'd rather not hold.
+     */
+    fun supportedRoles(lowMemoryMode: Boolean): List<String> = buildList {
+        add(SendSpinProtocol.Roles.PLAYER)
+        add(SendSpinProtocol.Roles.CONTROLLER)
+        add(SendSpinProtocol.Roles.METADATA)
+        if (!lowMemoryMode) add(SendSpinProtocol.Roles.ARTWORK)
+    }
+
     fun buildClientHello(
         clientId: String,
         deviceName: String,
@@ -32,11 +43,8 @@ object MessageBuilder {
                 put("name", deviceName)
                 put("version", SendSpinProtocol.VERSION)
                 put("supported_roles", buildJsonArray {
-                    add(kotlinx.serialization.json.JsonPrimitive(SendSpinProtocol.Roles.PLAYER))
-                    add(kotlinx.serialization.json.JsonPrimitive(SendSpinProtocol.Roles.CONTROLLER))
-                    add(kotlinx.serialization.json.JsonPrimitive(SendSpinProtocol.Roles.METADATA))
-                    if (!lowMemoryMode) {
-                        add(kotlinx.serialization.json.JsonPrimitive(SendSpinProtocol.Roles.ARTWORK))
+                    for (role in supportedRoles(lowMemoryMode)) {
+                        add(kotlinx.serialization.json.JsonPrimitive(role))
                     }
                 })
                 put("device_info", buildJsonObject {
@@ -257,4 +265,29 @@ object MessageBuilder {
             }
         }
     }
+
+    /**
+     * Narrow [formats] to what the current output device can play.
+     *
+     * An empty [sampleRates] or [bitDepths], or a non-positive [maxChannels],
+     * means the device did not report that dimension (AudioDeviceInfo returns
+     * an empty array for "any"), so it is not filtered. Compressed codecs
+     * decode to 16-bit PCM, so only PCM entries are checked against
+     * [bitDepths]. Order is preserved. If nothing survives, [formats] is
+     * returned unchanged: the platform mixer converts anyway, and advertising
+     * no format at all would fail the handshake.
+     */
+    fun filterFormatsForOutput(
+        formats: List<FormatEntry>,
+        sampleRates: List<Int>,
+        bitDepths: List<Int>,
+        maxChannels: Int
+    ): List<FormatEntry> {
+        val filtered = formats.filter { fmt ->
+            (sampleRates.isEmpty() || fmt.sampleRate in sampleRates) &&
+                (bitDepths.isEmpty() || fmt.codec != "pcm" || fmt.bitDepth in bitDepths) &&
+                (maxChannels <= 0 || fmt.channels <= maxChannels)
+        }
+        return filtered.ifEmpty { formats }
+    }
 }
@@ -1,5 +1,6 @@
 package com.sendspindroid.sendspin.protocol.message
 
+import com.sendspindroid.model.PlaybackStateType
 import com.sendspindroid.sendspin.protocol.ControllerState
 import com.sendspindroid.sendspin.protocol.GroupInfo
 import com.sendspindroid.sendspin.protocol.SendSpinProtocol
@@ -119,6 +120,7 @@ object MessageParser {
         }
 
         val state = payload.stringOrDefault("state", "").takeIf { it.isNotEmpty() }
+            ?.let { PlaybackStateType.fromString(it) }
 
         // Controller (group-level) state delta. All fields lenient: aiosendspin
         // sends the complete object, but spec delta semantics allow partials.
@@ -181,7 +183,9 @@ object MessageParser {
 
         val groupId = payload.stringOrDefault("group_id", "")
         val groupName = payload.stringOrDefault("group_name", "")
-        val playbackState = payload.stringOrDefault("playback_state", "")
+        // Null when absent: group/update is a delta, so no field means no change.
+        val playbackState = payload.stringOrDefault("playback_state", "").takeIf { it.isNotEmpty() }
+            ?.let { PlaybackStateType.fromString(it) }
 
         return GroupInfo(groupId, groupName, playbackState)
     }
@@ -36,6 +36,15 @@ class TimeSyncManager(
     private val tag: String = "TimeSyncManager"
 ) {
     companion object {
+        /** Default steady-state interval between bursts once converged. */
+        const val DEFAULT_CONVERGED_INTERVAL_MS = 3000L
+
+        // Bounds for convergedIntervalMs. The upper bound keeps idle-link
+        // silence (our bursts are the only regular traffic) well inside the
+        // client's 20s idle stall timeout.
+        const val MIN_CONVERGED_INTERVAL_MS = 500L
+        const val MAX_CONVERGED_INTERVAL_MS = 10_000L
+
         private const val MAX_ACCEPTABLE_RTT_US = 10_000_000L
         private const val RTT_HISTORY_SIZE = 15
         private const val BURST_COUNT_HIGH_JITTER = 15
@@ -43,7 +52,6 @@ class TimeSyncManager(
         private const val INTERVAL_MS_HIGH_JITTER = 200L
         private const val INTERVAL_MS_LOW_JITTER = 500L
         private const val BURST_COUNT_CONVERGED = 3
-        private const val INTERVAL_MS_CONVERGED = 3000L
         private const val HIGH_JITTER_THRESHOLD_US = 20_000L
         private const val LOW_JITTER_THRESHOLD_US = 5_000L
     }
@@ -66,6 +74,50 @@ class TimeSyncManager(
     val isRunning: Boolean
         get() = running
 
+    /**
+     * Interval between bursts once the filter has converged. Before that the
+     * schedule stays fast (200-500ms, by measured jitter) so convergence is
+     * quick; this only sets how far it backs off afterwards.
+     *
+     * Shorter intervals follow clock drift and route changes more closely;
+     * longer ones send less. Each converged burst is 3 small client/time
+     * and server/time pairs, so even the minimum is a few hundred bytes per
+     * second. Values outside [MIN_CONVERGED_INTERVAL_MS]..
+     * [MAX_CONVERGED_INTERVAL_MS] are clamped. Takes effect after the next
+     * burst.
+     */
+    @Volatile
+    var convergedIntervalMs: Long = DEFAULT_CONVERGED_INTERVAL_MS
+        set(value) {
+            if (value <= 0) {
+                Log.w(tag, "Invalid convergedIntervalMs $value, using default $DEFAULT_CONVERGED_INTERVAL_MS")
+                field = DEFAULT_CONVERGED_INTERVAL_MS
+                return
+            }
+            val clamped = value.coerceIn(MIN_CONVERGED_INTERVAL_MS, MAX_CONVERGED_INTERVAL_MS)
+            if (clamped != value) {
+                Log.w(tag, "convergedIntervalMs $value out of range, using $clamped")
+            }
+            field = clamped
+        }
+
+    /**
+     * When true, stop measuring: send a single client/time every
+     * [MAX_CONVERGED_INTERVAL_MS] and ignore the replies. The exchange is
+     * kept only because it is the regular traffic the client's idle stall
+     * watchdog relies on. Takes effect after the current wait.
+     */
+    @Volatile
+    var keepaliveOnly: Boolean = false
+
+    /**
+     * Interquartile range of recent burst RTTs in microseconds, the same
+     * jitter figure that drives the burst schedule. 0 until enough bursts
+     * have completed.
+     */
+    val rttJitterMicros: Long
+        get() = synchronized(pendingBurstMeasurements) { computeRttJitter() }
+
     // Visible for testing: burst strategy and RTT history state
     internal val testCurrentBurstCount: Int get() = synchronized(pendingBurstMeasurements) { currentBurstCount }
     internal val testCurrentIntervalMs: Long get() = synchronized(pendingBurstMeasurements) { currentIntervalMs }
@@ -76,12 +128,12 @@ class TimeSyncManager(
         running = true
 
         syncJob = scope.launch {
-            sendTimeSyncBurst()
+            sendNext()
 
             while (running && isActive) {
-                delay(currentIntervalMs)
+                delay(if (keepaliveOnly) MAX_CONVERGED_INTERVAL_MS else currentIntervalMs)
                 if (running) {
-                    sendTimeSyncBurst()
+                    sendNext()
                 }
             }
         }
@@ -107,11 +159,11 @@ class TimeSyncManager(
      * @return `true` if the measurement was buffered for the in-progress
      *   burst's best-of-RTT selection. `false` if it was processed
      *   immediately (out-of-burst path), dropped as stale, or arrived
-     *   while the manager is stopped. Callers that just want to forward
+     *   while the manager is stopped or [keepaliveOnly]. Callers that just want to forward
      *   the measurement can ignore the return value.
      */
     fun onServerTime(measurement: TimeMeasurement): Boolean {
-        if (!running) return false
+        if (!running || keepaliveOnly) return false
 
         synchronized(pendingBurstMeasurements) {
             if (burstInProgress) {
@@ -136,6 +188,14 @@ class TimeSyncManager(
         return false
     }
 
+    private suspend fun sendNext() {
+        if (keepaliveOnly) {
+            sendClientTime()
+        } else {
+            sendTimeSyncBurst()
+        }
+    }
+
     private suspend fun sendTimeSyncBurst() {
         synchronized(pendingBurstMeasurements) {
             pendingBurstMeasurements.clear()
@@ -210,8 +270,8 @@ class TimeSyncManager(
         if (rttHistoryCount < RTT_HISTORY_SIZE) rttHistoryCount++
     }
 
-    private fun updateBurstStrategy() {
-        if (rttHistoryCount < 5) return
+    private fun computeRttJitter(): Long {
+        if (rttHistoryCount < 5) return 0L
 
         val count = minOf(rttHistoryCount, RTT_HISTORY_SIZE)
         val sorted = LongArray(count)
@@ -222,7 +282,13 @@ class TimeSyncManager(
 
         val q1 = sorted[count / 4]
         val q3 = sorted[(count * 3) / 4]
-        val jitter = q3 - q1
+        return q3 - q1
+    }
+
+    private fun updateBurstStrategy() {
+        if (rttHistoryCount < 5) return
+
+        val jitter = computeRttJitter()
 
         when {
             jitter > HIGH_JITTER_THRESHOLD_US -> {
@@ -241,7 +307,7 @@ class TimeSyncManager(
 
         if (timeFilter.isConverged) {
             currentBurstCount = BURST_COUNT_CONVERGED
-            currentIntervalMs = INTERVAL_MS_CONVERGED
+            currentIntervalMs = convergedIntervalMs
         }
     }
 }
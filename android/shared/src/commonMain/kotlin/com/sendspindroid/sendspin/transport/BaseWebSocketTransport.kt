@@ -4,7 +4,9 @@ import com.sendspindroid.shared.log.Log
 import io.ktor.client.HttpClient
 import io.ktor.client.plugins.websocket.webSocket
 import io.ktor.client.request.HttpRequestBuilder
+import io.ktor.websocket.CloseReason
 import io.ktor.websocket.Frame
+import io.ktor.websocket.close
 import io.ktor.websocket.readBytes
 import io.ktor.websocket.readText
 import kotlinx.coroutines.CancellationException
@@ -31,16 +33,42 @@ import kotlin.concurrent.atomics.ExperimentalAtomicApi
  * This class is thread-safe. All state changes are atomic, and Ktor handles
  * WebSocket coroutines internally.
  *
+ * ## Frame Size Limit
+ * Frames larger than [maxFrameBytes] are treated as a protocol violation: the
+ * session is closed with 1009 (Message Too Big) and reported through
+ * [SendSpinTransport.Listener.onClosed] instead of being decoded and handed to
+ * the listener.
+ *
+ * This is a post-receive drop, not a read limit. The OkHttp engine has no
+ * read limit and ignores Ktor's `maxFrameSize`, so the whole frame has
+ * already been buffered in memory when the check runs; a frame too big for
+ * the heap still fails inside OkHttp (surfacing as [SendSpinTransport.Listener.onFailure]).
+ * What the guard does bound is the copy, decode, and listener work done
+ * with it, and it ends the session with a server that sent garbage.
+ *
  * @param tag Log tag for this transport instance
  * @param httpClient Ktor HttpClient configured for WebSocket connections
+ * @param maxFrameBytes Largest frame payload passed on, in bytes, checked
+ *   after receipt; zero or negative falls back to [DEFAULT_MAX_FRAME_BYTES]
  */
 @OptIn(ExperimentalAtomicApi::class)
 abstract class BaseWebSocketTransport(
     protected val tag: String,
-    protected val httpClient: HttpClient
+    protected val httpClient: HttpClient,
+    maxFrameBytes: Int = DEFAULT_MAX_FRAME_BYTES
 ) : SendSpinTransport {
 
     companion object {
+        /**
+         * Default frame size limit. Comfortably above the largest legitimate
+         * frames (full-size artwork is well under a megabyte; audio chunks are
+         * a few KB).
+         */
+        const val DEFAULT_MAX_FRAME_BYTES = 16 * 1024 * 1024
+
+        /** Close code for an oversized frame (RFC 6455 "Message Too Big"). */
+        const val CLOSE_CODE_TOO_BIG = 1009
+
         /**
          * Create a default Ktor HttpClient configured for WebSocket connections.
          *
@@ -55,6 +83,13 @@ abstract class BaseWebSocketTransport(
         ): HttpClient = createWebSocketHttpClient(pingIntervalSeconds, connectTimeoutMs)
     }
 
+    protected val maxFrameBytes: Int = if (maxFrameBytes > 0) {
+        maxFrameBytes
+    } else {
+        Log.w(tag, "Invalid maxFrameBytes $maxFrameBytes, using default $DEFAULT_MAX_FRAME_BYTES")
+        DEFAULT_MAX_FRAME_BYTES
+    }
+
     private val _state = AtomicReference(TransportState.Disconnected)
     override val state: TransportState get() = _state.load()
 
@@ -181,8 +216,13 @@ abstract class BaseWebSocketTransport(
                     }
 
                     // Receive loop
+                    var oversizedFrameBytes = -1
                     try {
                         for (frame in incoming) {
+                            if (frame.data.size > maxFrameBytes) {
+                                oversizedFrameBytes = frame.data.size
+                                break
+                            }
                             when (frame) {
                                 is Frame.Text -> {
                                     val txt = frame.readText()
@@ -206,6 +246,18 @@ abstract class BaseWebSocketTransport(
 
                     senderJob.cancel()
 
+                    if (oversizedFrameBytes >= 0) {
+                        Log.w(tag, "Oversized frame: $oversizedFrameBytes bytes (limit $maxFrameBytes), closing")
+                        // Best effort: the peer may already be gone
+                        try {
+                            close(CloseReason(CLOSE_CODE_TOO_BIG.toShort(), "Frame too large"))
+                        } catch (_: Exception) {
+                        }
+                        _state.store(TransportState.Closed)
+                        listener?.onClosed(CLOSE_CODE_TOO_BIG, "frame too large")
+                        return@webSocket
+                    }
+
                     // Session ended normally
                     val reason = closeReason.await()
                     val code = reason?.code?.toInt() ?: 1000
@@ -28,16 +28,20 @@ import io.ktor.client.request.header
  * @param pingIntervalSeconds Ping interval in seconds (default: 30, 15 in High Power Mode)
  * @param connectTimeoutMs Connect timeout in milliseconds (default: 10000)
  * @param httpClient Optional Ktor HttpClient (creates one if not provided)
+ * @param maxFrameBytes Largest frame payload passed on; larger frames are dropped after
+ *   receipt and close the session with 1009 (see [BaseWebSocketTransport])
  */
 class ProxyWebSocketTransport(
     private val url: String,
     private val authToken: String? = null,
     pingIntervalSeconds: Long = 30,
     connectTimeoutMs: Long = 10000,
-    httpClient: HttpClient = createDefaultClient(pingIntervalSeconds, connectTimeoutMs)
+    httpClient: HttpClient = createDefaultClient(pingIntervalSeconds, connectTimeoutMs),
+    maxFrameBytes: Int = DEFAULT_MAX_FRAME_BYTES
 ) : BaseWebSocketTransport(
     tag = TAG,
-    httpClient = httpClient
+    httpClient = httpClient,
+    maxFrameBytes = maxFrameBytes
 ) {
 
     companion object {